@@ -8,17 +8,26 @@ package rkboot
 
 import (
 	"context"
+	"crypto/ed25519"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strings"
+	"sync"
 
 	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
 	rkmid "github.com/rookie-ninja/rk-entry/v2/middleware"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
+// osExit would be called after printing effective config
+var osExit = os.Exit
+
 type hookFuncM map[string]map[string]func(ctx context.Context)
 
 func newHookFuncM() hookFuncM {
@@ -45,15 +54,70 @@ func (m hookFuncM) getFunc(entryType, entryName string) func(ctx context.Context
 
 // Boot is a structure for bootstrapping rk style application
 type Boot struct {
-	bootConfigPath string    `yaml:"-" json:"-"`
-	embedFS        *embed.FS `yaml:"-" json:"-"`
-	bootConfigRaw  []byte    `yaml:"-" json:"-"`
-	beforeHookF    hookFuncM `yaml:"-" json:"-"`
-	afterHookF     hookFuncM `yaml:"-" json:"-"`
-	EventId        string    `yaml:"-" json:"-"`
-	pluginEntries  map[string]map[string]rkentry.Entry
-	userEntries    map[string]map[string]rkentry.Entry
-	webEntries     map[string]map[string]rkentry.Entry
+	bootConfigPath      string                    `yaml:"-" json:"-"`
+	configFS            fs.FS                     `yaml:"-" json:"-"`
+	bootConfigRaw       []byte                    `yaml:"-" json:"-"`
+	configFormat        ConfigFormat              `yaml:"-" json:"-"`
+	config              *yaml.Node                `yaml:"-" json:"-"`
+	sources             configProvenance          `yaml:"-" json:"-"`
+	strictConfig        bool                      `yaml:"-" json:"-"`
+	args                []string                  `yaml:"-" json:"-"`
+	environ             []string                  `yaml:"-" json:"-"`
+	redactPatterns      []string                  `yaml:"-" json:"-"`
+	secretResolvers     map[string]SecretResolver `yaml:"-" json:"-"`
+	decryptionKeyFile   string                    `yaml:"-" json:"-"`
+	decryptionKeyEnv    string                    `yaml:"-" json:"-"`
+	configPublicKey     ed25519.PublicKey         `yaml:"-" json:"-"`
+	configPublicKeyFile string                    `yaml:"-" json:"-"`
+	beforeHookF         hookFuncM                 `yaml:"-" json:"-"`
+	afterHookF          hookFuncM                 `yaml:"-" json:"-"`
+	EventId             string                    `yaml:"-" json:"-"`
+	pluginEntries       map[string]map[string]rkentry.Entry
+	userEntries         map[string]map[string]rkentry.Entry
+	webEntries          map[string]map[string]rkentry.Entry
+	entryKeys           map[string]string
+	configFiles         []string
+	urlSource           *urlSource
+	dirSource           *dirSource
+	dotEnvPaths         []string
+	profiles            []string
+	hostname            string
+	portPreflight       bool
+	addrFile            string
+	optionEntries       []*optionEntry
+	watchConfig         bool
+	watcher             *configWatcher
+	reloadLock          sync.Mutex
+	configLock          sync.RWMutex
+}
+
+// EntryTier defines sequence of entries while bootstrapping
+type EntryTier int
+
+const (
+	// PluginTier entries would be bootstrapped first
+	PluginTier EntryTier = iota
+	// UserTier entries would be bootstrapped after plugin entries
+	UserTier
+	// WebTier entries would be bootstrapped at last
+	WebTier
+)
+
+// EntryOption is used as options while adding entry from code
+type EntryOption func(*Boot, rkentry.Entry)
+
+// WithEntryHookFuncBeforeBootstrap run function before entry Bootstrap()
+func WithEntryHookFuncBeforeBootstrap(f func(ctx context.Context)) EntryOption {
+	return func(boot *Boot, entry rkentry.Entry) {
+		boot.AddHookFuncBeforeBootstrap(entry.GetType(), entry.GetName(), f)
+	}
+}
+
+// WithEntryHookFuncAfterBootstrap run function after entry Bootstrap()
+func WithEntryHookFuncAfterBootstrap(f func(ctx context.Context)) EntryOption {
+	return func(boot *Boot, entry rkentry.Entry) {
+		boot.AddHookFuncAfterBootstrap(entry.GetType(), entry.GetName(), f)
+	}
 }
 
 // BootOption is used as options while bootstrapping from code
@@ -63,10 +127,48 @@ type BootOption func(*Boot)
 func WithBootConfigPath(filePath string, fs *embed.FS) BootOption {
 	return func(boot *Boot) {
 		boot.bootConfigPath = filePath
-		boot.embedFS = fs
+		if fs != nil {
+			boot.configFS = fs
+		}
+	}
+}
+
+// WithBootConfigFS provide boot config yaml file in fs.FS, like os.DirFS, embed.FS or fstest.MapFS.
+//
+// filePath should be a slash separated path in fsys as fs.ValidPath requires.
+func WithBootConfigFS(fsys fs.FS, filePath string) BootOption {
+	return func(boot *Boot) {
+		boot.bootConfigPath = filePath
+		boot.configFS = fsys
+	}
+}
+
+// WithBootConfigFormat provide format of boot config, format would be detected by extension of file if missing.
+func WithBootConfigFormat(format ConfigFormat) BootOption {
+	return func(boot *Boot) {
+		boot.configFormat = format
+	}
+}
+
+// WithEntry provide entry constructed from code, see Boot.AddEntry for details.
+//
+// The entry would be registered after boot config loaded successfully, before entries declared in boot config,
+// so that they could reference it. NewBoot would fail if boot config declares entry with the same type and name.
+func WithEntry(entry rkentry.Entry, tier EntryTier, opts ...EntryOption) BootOption {
+	return func(boot *Boot) {
+		if entry != nil {
+			boot.optionEntries = append(boot.optionEntries, &optionEntry{entry: entry, tier: tier, opts: opts})
+		}
 	}
 }
 
+// optionEntry is entry provided by WithEntry
+type optionEntry struct {
+	entry rkentry.Entry
+	tier  EntryTier
+	opts  []EntryOption
+}
+
 // WithBootConfigRaw provide boot config as string.
 func WithBootConfigRaw(raw []byte) BootOption {
 	return func(boot *Boot) {
@@ -76,10 +178,20 @@ func WithBootConfigRaw(raw []byte) BootOption {
 	}
 }
 
-// NewBoot create a bootstrapper.
+// NewBoot create a bootstrapper, panic if any error occurs.
 func NewBoot(opts ...BootOption) *Boot {
 	defer syncLog("N/A")
 
+	boot, err := NewBootE(opts...)
+	if err != nil {
+		rkentry.ShutdownWithError(err)
+	}
+
+	return boot
+}
+
+// NewBootE create a bootstrapper, returns error instead of panic.
+func NewBootE(opts ...BootOption) (*Boot, error) {
 	boot := &Boot{
 		EventId:       rkmid.GenerateRequestId(nil),
 		beforeHookF:   newHookFuncM(),
@@ -87,45 +199,251 @@ func NewBoot(opts ...BootOption) *Boot {
 		pluginEntries: map[string]map[string]rkentry.Entry{},
 		userEntries:   map[string]map[string]rkentry.Entry{},
 		webEntries:    map[string]map[string]rkentry.Entry{},
+		entryKeys:     map[string]string{},
+		args:          os.Args[1:],
+		environ:       os.Environ(),
 	}
+	boot.secretResolvers = boot.defaultSecretResolvers()
+	boot.hostname, _ = os.Hostname()
 
 	for i := range opts {
 		opts[i](boot)
 	}
 
-	raw := boot.readYAML()
+	// Print JSON Schema of boot config and exit if --rk.print-schema was provided
+	if printed, err := boot.printSchemaIfRequested(os.Stdout); err != nil {
+		return nil, err
+	} else if printed {
+		osExit(0)
+	}
+
+	dotEnvFiles, err := boot.loadDotEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := boot.loadConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	boot.commitURLDocs()
+
+	// Print effective config and exit if --rk.print-config was provided
+	if printed, err := boot.printConfigIfRequested(os.Stdout); err != nil {
+		return nil, err
+	} else if printed {
+		osExit(0)
+	}
+
+	// Print lint issues and exit if --rk.lint was provided
+	if linted, code, err := boot.lintIfRequested(os.Stdout); err != nil {
+		return nil, err
+	} else if linted {
+		osExit(code)
+	}
+
+	// Reg funcs would receive config normalized as YAML
+	raw, err := yaml.Marshal(boot.config)
+	if err != nil {
+		return nil, err
+	}
 
 	// Register entries need to pre-build.
 	rkentry.BootstrapBuiltInEntryFromYAML(raw)
 
+	for _, v := range dotEnvFiles {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Info("Load dot env file",
+			zap.String("path", v.path),
+			zap.Strings("keys", v.keys))
+	}
+
+	for _, v := range loaded.skipped {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Info("Skip boot config by when condition", zap.String("path", v))
+	}
+
+	for _, v := range loaded.overrides {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Info("Override boot config",
+			zap.String("path", v.path),
+			zap.String("source", v.source.String()))
+	}
+
+	for _, v := range loaded.skippedOverrides {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn(v)
+	}
+
+	for _, v := range loaded.unknownKeys {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn(v)
+	}
+
+	// Entries provided by WithEntry are registered only after boot config loaded, so that failures of loading
+	// would not leave them in GlobalAppCtx. Entries added by reg funcs would be rolled back on failures as well.
+	snapshot := snapshotGlobalEntries()
+	if err := boot.addOptionEntries(); err != nil {
+		return nil, err
+	}
+
+	if err := boot.registerEntries(raw); err != nil {
+		restoreGlobalEntries(snapshot)
+		return nil, err
+	}
+
+	return boot, nil
+}
+
+// addOptionEntries register entries provided by WithEntry, entries already declared in boot config like
+// built-in logger entries would be treated as conflicts
+func (boot *Boot) addOptionEntries() error {
+	for _, v := range boot.optionEntries {
+		if e := rkentry.GlobalAppCtx.GetEntry(v.entry.GetType(), v.entry.GetName()); e != nil && e != v.entry {
+			return fmt.Errorf("entry %s provided by WithEntry conflicts with entry declared in boot config", entryKey(v.entry))
+		}
+	}
+
+	for _, v := range boot.optionEntries {
+		boot.AddEntry(v.entry, v.tier, v.opts...)
+	}
+
+	return nil
+}
+
+// snapshotGlobalEntries returns copy of entries in GlobalAppCtx keyed by type and name
+func snapshotGlobalEntries() map[string]map[string]rkentry.Entry {
+	res := map[string]map[string]rkentry.Entry{}
+	for entryType, byName := range rkentry.GlobalAppCtx.ListEntries() {
+		res[entryType] = map[string]rkentry.Entry{}
+		for name, e := range byName {
+			res[entryType][name] = e
+		}
+	}
+
+	return res
+}
+
+// restoreGlobalEntries restore entries in GlobalAppCtx into snapshot, entries added after snapshot would be
+// removed and entries overwritten would be added back
+func restoreGlobalEntries(snapshot map[string]map[string]rkentry.Entry) {
+	for entryType, byName := range rkentry.GlobalAppCtx.ListEntries() {
+		for name, e := range byName {
+			if snapshot[entryType][name] != e {
+				rkentry.GlobalAppCtx.RemoveEntry(e)
+			}
+		}
+	}
+
+	for entryType, byName := range snapshot {
+		for name, e := range byName {
+			if rkentry.GlobalAppCtx.GetEntry(entryType, name) != e {
+				rkentry.GlobalAppCtx.AddEntry(e)
+			}
+		}
+	}
+}
+
+// checkOptionEntryConflict returns error if entry created by reg funcs has the same type and name with
+// entry provided by WithEntry
+func (boot *Boot) checkOptionEntryConflict(entry rkentry.Entry) error {
+	for _, v := range boot.optionEntries {
+		if entry != v.entry && entry.GetType() == v.entry.GetType() && entry.GetName() == v.entry.GetName() {
+			return fmt.Errorf("entry %s provided by WithEntry conflicts with entry declared in boot config", entryKey(entry))
+		}
+	}
+
+	return nil
+}
+
+// registerEntries create entries declared in boot config with reg funcs and verify them
+func (boot *Boot) registerEntries(raw []byte) error {
 	for _, f := range rkentry.ListPluginEntryRegFunc() {
 		for _, v := range f(raw) {
-			if boot.pluginEntries[v.GetType()] == nil {
-				boot.pluginEntries[v.GetType()] = make(map[string]rkentry.Entry)
+			if err := boot.checkOptionEntryConflict(v); err != nil {
+				return err
 			}
-			boot.pluginEntries[v.GetType()][v.GetName()] = v
+			boot.addEntry(v, PluginTier)
 		}
 	}
 
 	for _, f := range rkentry.ListUserEntryRegFunc() {
 		for _, v := range f(raw) {
-			if boot.userEntries[v.GetType()] == nil {
-				boot.userEntries[v.GetType()] = make(map[string]rkentry.Entry)
+			if err := boot.checkOptionEntryConflict(v); err != nil {
+				return err
+			}
+			boot.addEntry(v, UserTier)
+		}
+	}
+
+	for _, f := range userEntryRegFuncs {
+		entries, err := f.regF(raw)
+		if err != nil {
+			return err
+		}
+		for _, v := range entries {
+			if err := boot.checkOptionEntryConflict(v); err != nil {
+				return err
 			}
-			boot.userEntries[v.GetType()][v.GetName()] = v
+			boot.AddEntry(v, UserTier)
+			boot.entryKeys[entryKey(v)] = f.yamlKey
 		}
 	}
 
 	for _, f := range rkentry.ListWebFrameEntryRegFunc() {
 		for _, v := range f(raw) {
-			if boot.webEntries[v.GetType()] == nil {
-				boot.webEntries[v.GetType()] = make(map[string]rkentry.Entry)
+			if err := boot.checkOptionEntryConflict(v); err != nil {
+				return err
 			}
-			boot.webEntries[v.GetType()][v.GetName()] = v
+			boot.addEntry(v, WebTier)
 		}
 	}
 
-	return boot
+	// Entries referenced by fields like certEntry must exist after all entries registered
+	if err := boot.checkEntryReferences(boot.config); err != nil {
+		return err
+	}
+
+	// Ports of web entries declared with 0 would be allocated, and must be unique and available before any entry starts
+	if err := boot.allocatePorts(); err != nil {
+		return err
+	}
+
+	if err := boot.checkPorts(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddEntry add entry constructed from code into boot.
+//
+// The entry would be registered into rkentry.GlobalAppCtx, bootstrapped with entries of the same tier
+// and interrupted while shutting down, exactly like entries declared in boot config.
+func (boot *Boot) AddEntry(entry rkentry.Entry, tier EntryTier, opts ...EntryOption) {
+	if entry == nil {
+		return
+	}
+
+	rkentry.GlobalAppCtx.AddEntry(entry)
+	boot.addEntry(entry, tier)
+
+	for i := range opts {
+		opts[i](boot, entry)
+	}
+}
+
+// addEntry put entry into map of tier
+func (boot *Boot) addEntry(entry rkentry.Entry, tier EntryTier) {
+	var m map[string]map[string]rkentry.Entry
+	switch tier {
+	case PluginTier:
+		m = boot.pluginEntries
+	case WebTier:
+		m = boot.webEntries
+	default:
+		m = boot.userEntries
+	}
+
+	if m[entry.GetType()] == nil {
+		m[entry.GetType()] = make(map[string]rkentry.Entry)
+	}
+	m[entry.GetType()][entry.GetName()] = entry
 }
 
 // AddHookFuncBeforeBootstrap run functions before certain entry Bootstrap()
@@ -152,6 +470,11 @@ func (boot *Boot) Bootstrap(ctx context.Context) {
 
 	ctx = context.WithValue(ctx, "eventId", boot.EventId)
 
+	// Entries added from code after NewBoot may declare port 0 as well
+	if err := boot.allocatePorts(); err != nil {
+		rkentry.ShutdownWithError(err)
+	}
+
 	for entryType, byEntryName := range boot.pluginEntries {
 		for entryName, e := range byEntryName {
 			boot.beforeHookF.getFunc(entryType, entryName)(ctx)
@@ -175,6 +498,16 @@ func (boot *Boot) Bootstrap(ctx context.Context) {
 			boot.afterHookF.getFunc(entryType, entryName)(ctx)
 		}
 	}
+
+	if err := boot.reportAddrs(); err != nil {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn("Failed to report bound addresses", zap.Error(err))
+	}
+
+	if boot.watchConfig {
+		if err := boot.startWatch(); err != nil {
+			rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn("Failed to watch boot config", zap.Error(err))
+		}
+	}
 }
 
 // WaitForShutdownSig wait for shutdown signal.
@@ -190,6 +523,8 @@ func (boot *Boot) WaitForShutdownSig(ctx context.Context) {
 // 1: Call shutdown hook function added by user.
 // 2: Call interrupt function of entries in rkentry.GlobalAppCtx.
 func (boot *Boot) Shutdown(ctx context.Context) {
+	boot.stopWatch()
+
 	// Call shutdown hook function
 	for _, f := range rkentry.GlobalAppCtx.ListShutdownHooks() {
 		f()
@@ -218,36 +553,165 @@ func (boot *Boot) interrupt(ctx context.Context) {
 	}
 }
 
-// readYAML read YAML file
-func (boot *Boot) readYAML() []byte {
-	// case 1: if user provide raw then, continue
-	if len(boot.bootConfigRaw) > 0 {
-		return boot.bootConfigRaw
+// loadedConfig is result of loading boot config
+type loadedConfig struct {
+	// overrides applied
+	overrides []*configOverride
+	// unknownKeys is messages of unknown keys
+	unknownKeys []string
+	// skipped is paths of items whose when conditions are not matched
+	skipped []string
+	// skippedOverrides is messages of overrides from environment variables whose path doesn't exist
+	skippedOverrides []string
+}
+
+// configSnapshot returns boot config and sources of it, they are replaced as a whole while reloading and
+// never modified after published, so callers could read them without lock
+func (boot *Boot) configSnapshot() (*yaml.Node, configProvenance) {
+	boot.configLock.RLock()
+	defer boot.configLock.RUnlock()
+
+	return boot.config, boot.sources
+}
+
+// loadConfig read boot config, apply defaults section, strip conditional items, apply overrides, resolve secrets and decrypt values in it.
+//
+// boot.config and boot.sources would be replaced.
+func (boot *Boot) loadConfig(ctx context.Context) (*loadedConfig, error) {
+	var err error
+	if boot.config, err = boot.readYAML(); err != nil {
+		return nil, err
 	}
 
-	// case 2: if embed.FS is not nil, then try to read from it
-	if boot.embedFS != nil {
-		res, err := boot.embedFS.ReadFile(boot.bootConfigPath)
-		if err != nil {
-			rkentry.ShutdownWithError(err)
+	defaults, err := applyDefaultsSection(boot.config, boot.sources)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &loadedConfig{}
+	if res.skipped, err = boot.stripConditional(boot.config); err != nil {
+		return nil, err
+	}
+
+	if res.overrides, res.skippedOverrides, err = boot.applyOverrides(); err != nil {
+		return nil, err
+	}
+
+	if err := boot.resolveSecrets(ctx, boot.config); err != nil {
+		return nil, err
+	}
+
+	if err := boot.decryptValues(boot.config); err != nil {
+		return nil, err
+	}
+
+	keys, complete := configKeys()
+	res.unknownKeys = checkUnknownKeys(boot.config, keys, complete)
+	if defaults != nil {
+		// keys in defaults section would never be checked in items if items don't exist or override them
+		checkUnknownSections(defaults, defaultsKey, keys, complete, &res.unknownKeys)
+	}
+	if boot.strictConfig && len(res.unknownKeys) > 0 {
+		return nil, fmt.Errorf("invalid boot config, %s", strings.Join(res.unknownKeys, "; "))
+	}
+
+	return res, nil
+}
+
+// readYAML read boot config, resolve include directives in it and record source of values
+func (boot *Boot) readYAML() (*yaml.Node, error) {
+	// files read from local file system would be recorded for watching
+	boot.configFiles = nil
+
+	var src configSource = trackedSource{configSource: osSource{}, files: &boot.configFiles}
+	switch {
+	case boot.urlSource != nil:
+		// documents staged by previous failed loading would not be committed
+		boot.urlSource.discard()
+		src = boot.urlSource
+	case boot.configFS != nil:
+		src = fsSource{fsys: boot.configFS}
+	}
+
+	publicKey, err := boot.publicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	name, raw := rawConfigName, boot.bootConfigRaw
+	if publicKey != nil {
+		// raw boot config has no detached signature
+		if len(raw) > 0 {
+			return nil, errors.New("signature of raw boot config could not be verified, please provide it as file")
 		}
-		return res
+		src = verifiedSource{configSource: src, publicKey: publicKey}
 	}
 
-	// case 3: try to read from local, if bootConfigPath is empty, then try to read from default boot.yaml
-	if len(boot.bootConfigPath) < 1 {
-		boot.bootConfigPath = "boot.yaml"
+	loader := newConfigLoader(src)
+
+	// case 1: if user provide raw then, continue
+	if len(raw) < 1 && boot.dirSource != nil {
+		// case 2: merge files in directory
+		root, err := boot.dirSource.read(loader)
+		if err != nil {
+			return nil, err
+		}
+		boot.sources = loader.sources
+
+		return root, nil
+	} else if len(raw) < 1 && boot.urlSource != nil {
+		// case 3: read from URL
+		name = boot.urlSource.url
+		if raw, err = src.readFile(name); err != nil {
+			return nil, err
+		}
+	} else if len(raw) < 1 {
+		// case 4: if bootConfigPath is empty, then try to read from default boot.yaml
+		if len(boot.bootConfigPath) < 1 {
+			boot.bootConfigPath = "boot.yaml"
+		}
+
+		// case 5: try to read from local if fs.FS is nil
+		if boot.configFS == nil && !filepath.IsAbs(boot.bootConfigPath) {
+			wd, _ := os.Getwd()
+			boot.bootConfigPath = filepath.Join(wd, boot.bootConfigPath)
+		}
+
+		name = boot.bootConfigPath
+
+		if raw, err = src.readFile(name); err != nil {
+			return nil, err
+		}
 	}
-	if !filepath.IsAbs(boot.bootConfigPath) {
-		wd, _ := os.Getwd()
-		boot.bootConfigPath = filepath.Join(wd, boot.bootConfigPath)
+
+	format := boot.configFormat
+	if len(format) < 1 {
+		format = configFormatOf(name)
 	}
 
-	res, err := os.ReadFile(boot.bootConfigPath)
+	root, err := parseConfig(raw, format)
 	if err != nil {
-		rkentry.ShutdownWithError(err)
+		return nil, err
+	}
+
+	loader.sources.annotate(root, ConfigSource{Kind: ConfigSourceFile, File: name})
+	if err := loader.resolveIncludes(root, []string{name}); err != nil {
+		return nil, err
+	}
+	boot.sources = loader.sources
+
+	return root, nil
+}
+
+// commitURLDocs commit documents fetched from URL as last-known-good boot config
+func (boot *Boot) commitURLDocs() {
+	if boot.urlSource == nil {
+		return
+	}
+
+	if err := boot.urlSource.commit(); err != nil {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn("Failed to save boot config cache", zap.Error(err))
 	}
-	return res
 }
 
 // sync logs
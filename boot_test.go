@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"syscall"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -69,6 +70,111 @@ func TestNewBoot_WithEmbedCase(t *testing.T) {
 	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut"))
 }
 
+func TestNewBoot_WithFSCase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/boot.yaml": &fstest.MapFile{Data: []byte("myEntry:\n  name: ut-fs\n  enabled: true")},
+	}
+
+	boot := NewBoot(WithBootConfigFS(fsys, "conf/boot.yaml"))
+	assert.NotNil(t, boot.userEntries["myEntry"]["ut-fs"])
+	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-fs"))
+
+	// JSON and TOML detected by extension
+	fsys["boot.json"] = &fstest.MapFile{Data: []byte(`{"myEntry": {"name": "ut-json", "enabled": true}}`)}
+	fsys["boot.toml"] = &fstest.MapFile{Data: []byte("[myEntry]\nname = \"ut-toml\"\nenabled = true")}
+
+	boot = NewBoot(WithBootConfigFS(fsys, "boot.json"))
+	assert.NotNil(t, boot.userEntries["myEntry"]["ut-json"])
+	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-json"))
+
+	boot = NewBoot(WithBootConfigFS(fsys, "boot.toml"))
+	assert.NotNil(t, boot.userEntries["myEntry"]["ut-toml"])
+	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-toml"))
+
+	// explicit format
+	boot = NewBoot(
+		WithBootConfigRaw([]byte(`{"myEntry": {"name": "ut-raw-json", "enabled": true}}`)),
+		WithBootConfigFormat(ConfigFormatJSON))
+	assert.NotNil(t, boot.userEntries["myEntry"]["ut-raw-json"])
+	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-raw-json"))
+
+	// missing file
+	boot, err := NewBootE(WithBootConfigFS(fsys, "boot.yaml"))
+	assert.Nil(t, boot)
+	assert.NotNil(t, err)
+}
+
+func TestBoot_AddEntry(t *testing.T) {
+	fromOption := &MyEntry{EntryName: "ut-option", EntryType: "myEntry"}
+	fromCode := &MyEntry{EntryName: "ut-code", EntryType: "myEntry"}
+
+	triggerBefore := false
+	triggerAfter := false
+
+	boot := NewBoot(
+		WithBootConfigRaw([]byte("---")),
+		WithEntry(fromOption, PluginTier))
+	boot.AddEntry(fromCode, WebTier,
+		WithEntryHookFuncBeforeBootstrap(func(ctx context.Context) {
+			triggerBefore = true
+		}),
+		WithEntryHookFuncAfterBootstrap(func(ctx context.Context) {
+			triggerAfter = true
+		}))
+
+	assert.Equal(t, fromOption, boot.pluginEntries["myEntry"]["ut-option"])
+	assert.Equal(t, fromCode, boot.webEntries["myEntry"]["ut-code"])
+	assert.Equal(t, fromCode, rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-code"))
+
+	boot.Bootstrap(context.TODO())
+	assert.True(t, triggerBefore)
+	assert.True(t, triggerAfter)
+
+	boot.interrupt(context.TODO())
+
+	rkentry.GlobalAppCtx.RemoveEntry(fromOption)
+	rkentry.GlobalAppCtx.RemoveEntry(fromCode)
+}
+
+func TestNewBootE_WithEntryFailure(t *testing.T) {
+	fromOption := &MyEntry{EntryName: "ut-option", EntryType: "myEntry"}
+
+	// entry is not registered if boot config is invalid
+	boot, err := NewBootE(
+		WithBootConfigRaw([]byte("myEntry: [")),
+		WithEntry(fromOption, UserTier))
+	assert.Nil(t, boot)
+	assert.NotNil(t, err)
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-option"))
+
+	// entry is removed if entries declared in boot config fail, so are entries created by reg funcs
+	boot, err = NewBootE(
+		WithBootConfigRaw([]byte("myEntry:\n  name: ut-rollback\n  enabled: true\ngin:\n  - name: greeter\n    enabled: true\n    certEntry: ut-missing\n")),
+		WithEntry(fromOption, UserTier))
+	assert.Nil(t, boot)
+	assert.NotNil(t, err)
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-option"))
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-rollback"))
+
+	// entry declared in boot config with the same type and name
+	boot, err = NewBootE(
+		WithBootConfigRaw([]byte("myEntry:\n  name: ut-option\n  enabled: true\n")),
+		WithEntry(fromOption, UserTier))
+	assert.Nil(t, boot)
+	assert.Contains(t, err.Error(), "entry myEntry/ut-option provided by WithEntry conflicts")
+	assert.Nil(t, rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-option"))
+
+	// entries registered before are restored
+	existing := &MyEntry{EntryName: "ut-existing", EntryType: "myEntry"}
+	rkentry.GlobalAppCtx.AddEntry(existing)
+	defer rkentry.GlobalAppCtx.RemoveEntry(existing)
+	boot, err = NewBootE(
+		WithBootConfigRaw([]byte("myEntry:\n  name: ut-existing\n  enabled: true\ngin:\n  - name: greeter\n    certEntry: ut-missing\n")))
+	assert.Nil(t, boot)
+	assert.NotNil(t, err)
+	assert.Equal(t, existing, rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-existing"))
+}
+
 func assertPanic(t *testing.T) {
 	if r := recover(); r != nil {
 		fmt.Println("adsfadfafd")
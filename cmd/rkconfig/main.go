@@ -0,0 +1,205 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+// Command rkconfig is a helper of boot config.
+//
+// Bellow sub commands are supported:
+//
+//	rkconfig keygen > config.key
+//	rkconfig encrypt -key config.key <value>
+//	rkconfig decrypt -key config.key 'ENC[aes256gcm:...]'
+//	rkconfig rotate -key config.key -new-key new.key [-w] boot.yaml
+//	rkconfig sign-keygen -out release
+//	rkconfig sign -key release.key boot.yaml [include.yaml...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rookie-ninja/rk-boot/v2"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "rkconfig:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes sub command in args
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 1 {
+		return fmt.Errorf("sub command is missing, available: keygen, encrypt, decrypt, rotate, sign-keygen, sign")
+	}
+
+	set := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	keyFile := set.String("key", "", "file of base64 encoded key")
+	out := set.String("out", "", "prefix of key pair files, used by sign-keygen")
+	newKeyFile := set.String("new-key", "", "file of new base64 encoded key, used by rotate")
+	write := set.Bool("w", false, "write result to file instead of stdout, used by rotate")
+	if err := set.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "keygen":
+		key, err := rkboot.GenerateConfigKey()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, key)
+		return err
+	case "encrypt", "decrypt":
+		key, err := readKey(*keyFile)
+		if err != nil {
+			return err
+		}
+
+		value, err := argOrStdin(set.Args(), stdin)
+		if err != nil {
+			return err
+		}
+
+		if args[0] == "encrypt" {
+			value, err = rkboot.EncryptConfigValue(key, value)
+		} else {
+			value, err = rkboot.DecryptConfigValue(key, value)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(stdout, value)
+		return err
+	case "rotate":
+		oldKey, err := readKey(*keyFile)
+		if err != nil {
+			return err
+		}
+		newKey, err := readKey(*newKeyFile)
+		if err != nil {
+			return err
+		}
+
+		if set.NArg() != 1 {
+			return fmt.Errorf("expect one boot config file to rotate")
+		}
+
+		raw, err := os.ReadFile(set.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		res, err := rkboot.RotateConfigValues(raw, oldKey, newKey)
+		if err != nil {
+			return err
+		}
+
+		if *write {
+			return writeFileKeepMode(set.Arg(0), res)
+		}
+		_, err = stdout.Write(res)
+		return err
+	case "sign-keygen":
+		if len(*out) < 1 {
+			return fmt.Errorf("prefix of key pair files is missing, please provide it with -out")
+		}
+
+		publicKey, privateKey, err := rkboot.GenerateSigningKey()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*out+".key", []byte(privateKey+"\n"), 0600); err != nil {
+			return err
+		}
+		return os.WriteFile(*out+".pub", []byte(publicKey+"\n"), 0644)
+	case "sign":
+		if len(*keyFile) < 1 {
+			return fmt.Errorf("key file is missing, please provide it with -key")
+		}
+
+		bytes, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		privateKey, err := rkboot.ParseSigningPrivateKey(string(bytes))
+		if err != nil {
+			return err
+		}
+
+		if set.NArg() < 1 {
+			return fmt.Errorf("expect boot config files to sign")
+		}
+
+		// every file including fragments referenced by $include needs a signature
+		for _, filePath := range set.Args() {
+			raw, err := os.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filePath+".sig", rkboot.SignConfig(privateKey, raw), 0644); err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, "signed", filePath)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown sub command %s, available: keygen, encrypt, decrypt, rotate, sign-keygen, sign", args[0])
+}
+
+// writeFileKeepMode replace content of file with a temporary file in the same directory, permissions of
+// original file are kept
+func writeFileKeepMode(filePath string, data []byte) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	tmp := filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	// permissions of new file are masked by umask
+	if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, filePath)
+}
+
+// readKey read base64 encoded key from file
+func readKey(filePath string) ([]byte, error) {
+	if len(filePath) < 1 {
+		return nil, fmt.Errorf("key file is missing, please provide it with -key")
+	}
+
+	bytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return rkboot.ParseConfigKey(string(bytes))
+}
+
+// argOrStdin returns first argument, or content of stdin if no argument was provided
+func argOrStdin(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	bytes, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(bytes), "\r\n"), nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rookie-ninja/rk-boot/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// runCmd run sub command and returns trimmed stdout
+func runCmd(t *testing.T, stdin string, args ...string) string {
+	stdout := &bytes.Buffer{}
+	assert.Nil(t, run(args, strings.NewReader(stdin), stdout))
+	return strings.TrimSpace(stdout.String())
+}
+
+func TestRun_EncryptRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "config.key")
+	newKeyFile := filepath.Join(dir, "new.key")
+	configFile := filepath.Join(dir, "boot.yaml")
+
+	// keygen
+	assert.Nil(t, os.WriteFile(keyFile, []byte(runCmd(t, "", "keygen")), 0600))
+	assert.Nil(t, os.WriteFile(newKeyFile, []byte(runCmd(t, "", "keygen")), 0600))
+
+	// encrypt from argument and stdin, decrypt
+	encrypted := runCmd(t, "", "encrypt", "-key", keyFile, "my-pass")
+	assert.True(t, strings.HasPrefix(encrypted, "ENC[aes256gcm:"))
+	assert.Equal(t, "my-pass", runCmd(t, encrypted+"\n", "decrypt", "-key", keyFile))
+
+	// rotate to stdout
+	assert.Nil(t, os.WriteFile(configFile, []byte("mysql:\n  pass: "+encrypted+"\n"), 0640))
+	rotated := runCmd(t, "", "rotate", "-key", keyFile, "-new-key", newKeyFile, configFile)
+	assert.NotContains(t, rotated, encrypted)
+
+	// rotate in place keeps permissions of file
+	runCmd(t, "", "rotate", "-key", keyFile, "-new-key", newKeyFile, "-w", configFile)
+	info, err := os.Stat(configFile)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	raw, _ := os.ReadFile(configFile)
+	value := strings.TrimSpace(strings.TrimPrefix(strings.Split(string(raw), "\n")[1], "  pass:"))
+	assert.Equal(t, "my-pass", runCmd(t, "", "decrypt", "-key", newKeyFile, value))
+
+	// old key doesn't work anymore
+	assert.NotNil(t, run([]string{"decrypt", "-key", keyFile, value}, strings.NewReader(""), &bytes.Buffer{}))
+}
+
+func TestRun_SignRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "release")
+	configFile := filepath.Join(dir, "boot.yaml")
+	raw := []byte("gin:\n  - name: greeter\n    port: 8080\n")
+	assert.Nil(t, os.WriteFile(configFile, raw, 0644))
+
+	runCmd(t, "", "sign-keygen", "-out", prefix)
+	info, err := os.Stat(prefix + ".key")
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.Equal(t, "signed "+configFile, runCmd(t, "", "sign", "-key", prefix+".key", configFile))
+
+	pub, _ := os.ReadFile(prefix + ".pub")
+	publicKey, err := rkboot.ParseSigningPublicKey(string(pub))
+	assert.Nil(t, err)
+
+	sig, err := os.ReadFile(configFile + ".sig")
+	assert.Nil(t, err)
+	assert.Nil(t, rkboot.VerifyConfig(publicKey, raw, sig))
+	assert.NotNil(t, rkboot.VerifyConfig(publicKey, append(raw, '#'), sig))
+}
+
+func TestRun_InvalidArgs(t *testing.T) {
+	stdout := &bytes.Buffer{}
+
+	assert.NotNil(t, run([]string{}, strings.NewReader(""), stdout))
+	assert.NotNil(t, run([]string{"unknown"}, strings.NewReader(""), stdout))
+	assert.NotNil(t, run([]string{"encrypt", "value"}, strings.NewReader(""), stdout))
+	assert.NotNil(t, run([]string{"sign-keygen"}, strings.NewReader(""), stdout))
+	assert.NotNil(t, run([]string{"sign", "-key", "missing.key", "boot.yaml"}, strings.NewReader(""), stdout))
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is format of boot config
+type ConfigFormat string
+
+const (
+	// ConfigFormatYAML is YAML format, which is the default format
+	ConfigFormatYAML ConfigFormat = "yaml"
+	// ConfigFormatJSON is JSON format
+	ConfigFormatJSON ConfigFormat = "json"
+	// ConfigFormatTOML is TOML format
+	ConfigFormatTOML ConfigFormat = "toml"
+)
+
+// configFormatOf returns format of boot config file by extension, YAML would be returned for unknown extension
+func configFormatOf(filePath string) ConfigFormat {
+	// ignore query of URL
+	if strings.Contains(filePath, "://") {
+		if i := strings.IndexAny(filePath, "?#"); i >= 0 {
+			filePath = filePath[:i]
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return ConfigFormatJSON
+	case ".toml":
+		return ConfigFormatTOML
+	}
+
+	return ConfigFormatYAML
+}
+
+// parseConfig parse boot config with format into root mapping node, empty config would be an empty mapping node
+func parseConfig(raw []byte, format ConfigFormat) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+
+	switch format {
+	case ConfigFormatJSON:
+		node, err := jsonToNode(json.NewDecoder(bytes.NewReader(raw)))
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to parse JSON boot config, %v", err)
+		}
+		if node != nil {
+			doc.Content = append(doc.Content, node)
+		}
+	case ConfigFormatTOML:
+		m := map[string]interface{}{}
+		if err := toml.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML boot config, %v", err)
+		}
+		node := &yaml.Node{}
+		if err := node.Encode(m); err != nil {
+			return nil, err
+		}
+		doc.Content = append(doc.Content, node)
+	case ConfigFormatYAML, "":
+		if err := yaml.Unmarshal(raw, doc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported boot config format %q", format)
+	}
+
+	if len(doc.Content) < 1 || doc.Content[0].Tag == "!!null" {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("boot config must be a mapping at line %d", root.Line)
+	}
+
+	return root, nil
+}
+
+// mergeNode deep merge mapping node of src into dst, values in src override values in dst
+func mergeNode(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		merged := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if !strings.EqualFold(dst.Content[j].Value, key.Value) {
+				continue
+			}
+
+			if dst.Content[j+1].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+				mergeNode(dst.Content[j+1], value)
+			} else {
+				dst.Content[j+1] = value
+			}
+			merged = true
+			break
+		}
+
+		if !merged {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+}
+
+// configPathSegment is one segment of path like gin.greeter.port or gin[0].port
+type configPathSegment struct {
+	key   string
+	index int
+}
+
+// isIndex returns true if segment is [index] of list
+func (s configPathSegment) isIndex() bool {
+	return s.index >= 0
+}
+
+// String returns segment as it is in path
+func (s configPathSegment) String() string {
+	if s.isIndex() {
+		return fmt.Sprintf("[%d]", s.index)
+	}
+
+	return s.key
+}
+
+// parseConfigPath parse path like gin.greeter.port or gin[0].port into segments
+func parseConfigPath(path string) ([]configPathSegment, error) {
+	res := make([]configPathSegment, 0)
+
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		indexes := make([]int, 0)
+
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+			for _, idx := range strings.Split(strings.TrimSuffix(part[i+1:], "]"), "][") {
+				v, err := strconv.Atoi(idx)
+				if err != nil || v < 0 {
+					return nil, fmt.Errorf("invalid index in config path %q", path)
+				}
+				indexes = append(indexes, v)
+			}
+		}
+
+		if len(key) < 1 && len(indexes) < 1 {
+			return nil, fmt.Errorf("empty segment in config path %q", path)
+		}
+
+		if len(key) > 0 {
+			res = append(res, configPathSegment{key: key, index: -1})
+		}
+		for _, v := range indexes {
+			res = append(res, configPathSegment{index: v})
+		}
+	}
+
+	return res, nil
+}
+
+// lookupConfigNode returns node of path, list items could be addressed either by [index] or by name field
+func lookupConfigNode(root *yaml.Node, path string) (*yaml.Node, error) {
+	segments, err := parseConfigPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := root
+	for _, seg := range segments {
+		if node = childNode(node, seg); node == nil {
+			return nil, nil
+		}
+	}
+
+	return node, nil
+}
+
+// childNode returns child of node with segment, nil if missing
+func childNode(node *yaml.Node, seg configPathSegment) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if seg.isIndex() {
+			return nil
+		}
+		_, v := mappingValue(node, seg.key)
+		return v
+	case yaml.SequenceNode:
+		if seg.isIndex() {
+			if seg.index < len(node.Content) {
+				return node.Content[seg.index]
+			}
+			return nil
+		}
+		return namedItem(node, seg.key)
+	}
+
+	return nil
+}
+
+// mappingValue returns key and value node in mapping node, key is matched case-sensitive first
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// namedItem returns item in sequence node whose name field equals to name
+func namedItem(node *yaml.Node, name string) *yaml.Node {
+	for _, item := range node.Content {
+		if itemName(item) == name {
+			return item
+		}
+	}
+
+	return nil
+}
+
+// itemName returns value of name field of mapping node
+func itemName(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+
+	if _, v := mappingValue(node, "name"); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+
+	return ""
+}
+
+// jsonToNode convert JSON into yaml.Node with order of keys preserved
+func jsonToNode(dec *json.Decoder) (*yaml.Node, error) {
+	dec.UseNumber()
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := token.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := jsonToNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprintf("%v", key)}, value)
+			}
+			_, err = dec.Token()
+			return node, err
+		case '[':
+			node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for dec.More() {
+				value, err := jsonToNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, value)
+			}
+			_, err = dec.Token()
+			return node, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", v)
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+}
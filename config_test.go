@@ -0,0 +1,122 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseConfig(t *testing.T) {
+	// empty config
+	root, err := parseConfig([]byte("---"), ConfigFormatYAML)
+	assert.Nil(t, err)
+	assert.Empty(t, root.Content)
+
+	// non-mapping config
+	root, err = parseConfig([]byte("- a\n- b"), ConfigFormatYAML)
+	assert.Nil(t, root)
+	assert.NotNil(t, err)
+
+	// invalid config
+	_, err = parseConfig([]byte("a: [b"), ConfigFormatYAML)
+	assert.NotNil(t, err)
+}
+
+func TestParseConfig_WithJSON(t *testing.T) {
+	root, err := parseConfig([]byte(`{"gin": [{"name": "greeter", "port": 8080, "enabled": true, "ratio": 0.5, "desc": null}]}`),
+		ConfigFormatJSON)
+	assert.Nil(t, err)
+
+	raw, _ := yaml.Marshal(root)
+	assert.Equal(t, "gin:\n    - name: greeter\n      port: 8080\n      enabled: true\n      ratio: 0.5\n      desc: null\n",
+		string(raw))
+
+	// empty config
+	root, err = parseConfig([]byte(""), ConfigFormatJSON)
+	assert.Nil(t, err)
+	assert.Empty(t, root.Content)
+
+	// invalid config
+	_, err = parseConfig([]byte(`{"gin": [`), ConfigFormatJSON)
+	assert.NotNil(t, err)
+}
+
+func TestParseConfig_WithTOML(t *testing.T) {
+	root, err := parseConfig([]byte(`
+[[gin]]
+name = "greeter"
+port = 8080
+enabled = true
+`), ConfigFormatTOML)
+	assert.Nil(t, err)
+
+	node, _ := lookupConfigNode(root, "gin.greeter.port")
+	assert.Equal(t, "8080", node.Value)
+	assert.Equal(t, "!!int", node.Tag)
+
+	// invalid config
+	_, err = parseConfig([]byte(`[[gin`), ConfigFormatTOML)
+	assert.NotNil(t, err)
+
+	// unsupported format
+	_, err = parseConfig([]byte(""), ConfigFormat("xml"))
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatOf(t *testing.T) {
+	assert.Equal(t, ConfigFormatJSON, configFormatOf("conf/boot.JSON"))
+	assert.Equal(t, ConfigFormatTOML, configFormatOf("boot.toml"))
+	assert.Equal(t, ConfigFormatYAML, configFormatOf("boot.yml"))
+	assert.Equal(t, ConfigFormatYAML, configFormatOf(""))
+}
+
+func TestParseConfigPath(t *testing.T) {
+	segments, err := parseConfigPath("gin[0].middleware.ignore[1]")
+	assert.Nil(t, err)
+	assert.Equal(t, []configPathSegment{
+		{key: "gin", index: -1},
+		{index: 0},
+		{key: "middleware", index: -1},
+		{key: "ignore", index: -1},
+		{index: 1},
+	}, segments)
+
+	_, err = parseConfigPath("gin..port")
+	assert.NotNil(t, err)
+
+	_, err = parseConfigPath("gin[x].port")
+	assert.NotNil(t, err)
+}
+
+func TestLookupConfigNode(t *testing.T) {
+	root, _ := parseConfig([]byte(`
+gin:
+  - name: greeter
+    port: 8080
+  - name: admin
+    port: 8081
+`), ConfigFormatYAML)
+
+	node, err := lookupConfigNode(root, "gin.admin.port")
+	assert.Nil(t, err)
+	assert.Equal(t, "8081", node.Value)
+
+	node, _ = lookupConfigNode(root, "gin[0].port")
+	assert.Equal(t, "8080", node.Value)
+
+	// key is case-insensitive
+	node, _ = lookupConfigNode(root, "GIN.greeter.Port")
+	assert.Equal(t, "8080", node.Value)
+
+	node, _ = lookupConfigNode(root, "gin.missing.port")
+	assert.Nil(t, node)
+
+	node, _ = lookupConfigNode(root, "gin[5]")
+	assert.Nil(t, node)
+}
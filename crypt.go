@@ -0,0 +1,225 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// encryptedPrefix is prefix of encrypted values in boot config, like ENC[aes256gcm:...]
+	encryptedPrefix = "ENC[aes256gcm:"
+	// configKeySize is size of AES-256 key
+	configKeySize = 32
+)
+
+// encryptedValueRegex matches encrypted values in boot config
+var encryptedValueRegex = regexp.MustCompile(`ENC\[aes256gcm:([A-Za-z0-9+/=]+)\]`)
+
+// WithDecryptionKeyFile provide file which contains base64 encoded key of decrypting ENC[aes256gcm:...] values.
+func WithDecryptionKeyFile(filePath string) BootOption {
+	return func(boot *Boot) {
+		boot.decryptionKeyFile = filePath
+	}
+}
+
+// WithDecryptionKeyEnv provide environment variable which contains base64 encoded key of decrypting
+// ENC[aes256gcm:...] values.
+func WithDecryptionKeyEnv(name string) BootOption {
+	return func(boot *Boot) {
+		boot.decryptionKeyEnv = name
+	}
+}
+
+// GenerateConfigKey returns base64 encoded random key of encrypting values in boot config.
+func GenerateConfigKey() (string, error) {
+	key := make([]byte, configKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseConfigKey decode base64 encoded key of encrypting values in boot config.
+func ParseConfigKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config key, %v", err)
+	}
+
+	if len(key) != configKeySize {
+		return nil, fmt.Errorf("invalid config key, expect %d bytes, got %d", configKeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// EncryptConfigValue encrypt plaintext with AES-256-GCM, returns value like ENC[aes256gcm:...]
+// which could be committed in boot config.
+func EncryptConfigValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + "]", nil
+}
+
+// DecryptConfigValue decrypt value like ENC[aes256gcm:...] with key.
+func DecryptConfigValue(key []byte, value string) (string, error) {
+	match := encryptedValueRegex.FindStringSubmatch(value)
+	if match == nil || match[0] != value {
+		return "", errors.New("invalid encrypted value, expect ENC[aes256gcm:...]")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value, ciphertext too short")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value, %v", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// RotateConfigValues re-encrypt all ENC[aes256gcm:...] values in raw with newKey, rest of raw would be kept as it is.
+func RotateConfigValues(raw []byte, oldKey, newKey []byte) ([]byte, error) {
+	var rotateErr error
+
+	res := encryptedValueRegex.ReplaceAllFunc(raw, func(match []byte) []byte {
+		if rotateErr != nil {
+			return match
+		}
+
+		plaintext, err := DecryptConfigValue(oldKey, string(match))
+		if err != nil {
+			rotateErr = err
+			return match
+		}
+
+		value, err := EncryptConfigValue(newKey, plaintext)
+		if err != nil {
+			rotateErr = err
+			return match
+		}
+
+		return []byte(value)
+	})
+
+	if rotateErr != nil {
+		return nil, rotateErr
+	}
+
+	return res, nil
+}
+
+// newGCM create AES-GCM cipher with key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decryptionKey returns key from file or environment variable
+func (boot *Boot) decryptionKey() ([]byte, error) {
+	if len(boot.decryptionKeyFile) > 0 {
+		bytes, err := os.ReadFile(boot.decryptionKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ParseConfigKey(string(bytes))
+	}
+
+	if len(boot.decryptionKeyEnv) > 0 {
+		for _, v := range boot.environ {
+			if tokens := strings.SplitN(v, "=", 2); len(tokens) == 2 && tokens[0] == boot.decryptionKeyEnv {
+				return ParseConfigKey(tokens[1])
+			}
+		}
+		return nil, fmt.Errorf("environment variable %s of decryption key not found", boot.decryptionKeyEnv)
+	}
+
+	return nil, errors.New("decryption key is missing, please provide it with WithDecryptionKeyFile or WithDecryptionKeyEnv")
+}
+
+// decryptValues decrypt ENC[aes256gcm:...] values in node, key would be loaded only if encrypted values exist
+func (boot *Boot) decryptValues(node *yaml.Node) error {
+	encrypted := make([]*yaml.Node, 0)
+	collectEncrypted(node, &encrypted)
+	if len(encrypted) < 1 {
+		return nil
+	}
+
+	key, err := boot.decryptionKey()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range encrypted {
+		plaintext, err := DecryptConfigValue(key, v.Value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt value at line %d, %v", v.Line, err)
+		}
+
+		// keep file and line of value, and mark node as secret which would be redacted in effective config
+		source := ConfigSource{}
+		if s, ok := boot.sources[v]; ok {
+			source = *s
+		}
+		source.Kind, source.Name = ConfigSourceSecret, encryptedPrefix+"...]"
+		boot.sources[v] = &source
+
+		v.Tag, v.Style, v.Value = "!!str", 0, plaintext
+	}
+
+	return nil
+}
+
+// collectEncrypted collect scalar nodes with encrypted values
+func collectEncrypted(node *yaml.Node, res *[]*yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		if strings.HasPrefix(node.Value, encryptedPrefix) {
+			*res = append(*res, node)
+		}
+		return
+	}
+
+	for _, v := range node.Content {
+		collectEncrypted(v, res)
+	}
+}
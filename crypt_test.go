@@ -0,0 +1,127 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptConfigValue(t *testing.T) {
+	encoded, err := GenerateConfigKey()
+	assert.Nil(t, err)
+	key, err := ParseConfigKey(encoded)
+	assert.Nil(t, err)
+
+	value, err := EncryptConfigValue(key, "my-pass")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(value, "ENC[aes256gcm:"))
+	assert.NotContains(t, value, "my-pass")
+
+	res, err := DecryptConfigValue(key, value)
+	assert.Nil(t, err)
+	assert.Equal(t, "my-pass", res)
+
+	// with wrong key
+	other, _ := GenerateConfigKey()
+	otherKey, _ := ParseConfigKey(other)
+	_, err = DecryptConfigValue(otherKey, value)
+	assert.NotNil(t, err)
+
+	// with invalid value
+	_, err = DecryptConfigValue(key, "my-pass")
+	assert.NotNil(t, err)
+
+	// with invalid key
+	_, err = ParseConfigKey("aW52YWxpZA==")
+	assert.NotNil(t, err)
+}
+
+func TestRotateConfigValues(t *testing.T) {
+	oldEncoded, _ := GenerateConfigKey()
+	oldKey, _ := ParseConfigKey(oldEncoded)
+	newEncoded, _ := GenerateConfigKey()
+	newKey, _ := ParseConfigKey(newEncoded)
+
+	pass, _ := EncryptConfigValue(oldKey, "my-pass")
+	token, _ := EncryptConfigValue(oldKey, "my-token")
+	raw := "# comment kept\nmysql:\n  - name: db\n    pass: " + pass + "\n    token: \"" + token + "\"\n"
+
+	res, err := RotateConfigValues([]byte(raw), oldKey, newKey)
+	assert.Nil(t, err)
+	assert.Contains(t, string(res), "# comment kept")
+	assert.NotContains(t, string(res), pass)
+	assert.NotContains(t, string(res), token)
+
+	matches := encryptedValueRegex.FindAllString(string(res), -1)
+	assert.Len(t, matches, 2)
+	v, err := DecryptConfigValue(newKey, matches[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "my-pass", v)
+	v, err = DecryptConfigValue(newKey, matches[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "my-token", v)
+
+	// with wrong old key
+	_, err = RotateConfigValues(res, oldKey, newKey)
+	assert.NotNil(t, err)
+}
+
+func TestBoot_DecryptValues(t *testing.T) {
+	encoded, _ := GenerateConfigKey()
+	key, _ := ParseConfigKey(encoded)
+	pass, _ := EncryptConfigValue(key, "my-pass")
+
+	config := `
+mysql:
+  - name: user-db
+    user: root
+    pass: ` + pass + `
+`
+
+	// with key file
+	keyFile := filepath.Join(t.TempDir(), "config.key")
+	assert.Nil(t, os.WriteFile(keyFile, []byte(encoded+"\n"), 0600))
+
+	boot := NewBoot(WithBootConfigRaw([]byte(config)), WithDecryptionKeyFile(keyFile))
+	node, _ := lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "my-pass", node.Value)
+
+	res, _ := boot.ConfigSource("mysql.user-db.pass")
+	assert.Equal(t, ConfigSourceSecret, res.Kind)
+
+	dump, err := boot.EffectiveConfig(ConfigFormatYAML)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(dump), "my-pass")
+
+	// with key env
+	boot = NewBoot(
+		WithBootConfigRaw([]byte(config)),
+		WithDecryptionKeyEnv("UT_CONFIG_KEY"),
+		func(boot *Boot) {
+			boot.environ = []string{"UT_CONFIG_KEY=" + encoded}
+		})
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "my-pass", node.Value)
+
+	// without key
+	_, err = NewBootE(WithBootConfigRaw([]byte(config)))
+	assert.NotNil(t, err)
+
+	// with wrong key
+	other, _ := GenerateConfigKey()
+	_, err = NewBootE(
+		WithBootConfigRaw([]byte(config)),
+		WithDecryptionKeyEnv("UT_CONFIG_KEY"),
+		func(boot *Boot) {
+			boot.environ = []string{"UT_CONFIG_KEY=" + other}
+		})
+	assert.NotNil(t, err)
+}
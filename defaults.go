@@ -0,0 +1,112 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultsKey is top level key of defaults applied to all items of a type in boot config.
+//
+//	defaults:
+//	  gin:
+//	    middleware:
+//	      logging:
+//	        enabled: true
+//	gin:
+//	  - name: greeter   # middleware.logging.enabled would be true
+//	  - name: admin
+//	    middleware:
+//	      logging:
+//	        enabled: false # item-level values win
+//
+// Keys in defaults section are validated like items, when conditions are not allowed in it.
+const defaultsKey = "defaults"
+
+// applyDefaultsSection deep merge defaults section into every item of the type and remove defaults section,
+// values copied from defaults would be recorded as ConfigSourceDefault in sources.
+//
+// Removed defaults section would be returned for validation.
+func applyDefaultsSection(root *yaml.Node, sources configProvenance) (*yaml.Node, error) {
+	var defaults *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == defaultsKey {
+			defaults = root.Content[i+1]
+			root.Content = append(root.Content[:i:i], root.Content[i+2:]...)
+			break
+		}
+	}
+
+	if defaults == nil || defaults.Tag == "!!null" {
+		return nil, nil
+	}
+
+	if defaults.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s must be a mapping of top level keys at line %d", defaultsKey, defaults.Line)
+	}
+
+	for i := 0; i+1 < len(defaults.Content); i += 2 {
+		key, def := defaults.Content[i], defaults.Content[i+1]
+		if def.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s.%s must be a mapping at line %d", defaultsKey, key.Value, def.Line)
+		}
+
+		// when conditions are evaluated per item, copying them into every item is hardly expected
+		if k, _ := mappingValue(def, whenKey); k != nil {
+			return nil, fmt.Errorf("%s is not allowed in %s.%s at line %d", whenKey, defaultsKey, key.Value, k.Line)
+		}
+
+		_, section := mappingValue(root, key.Value)
+		if section == nil {
+			continue
+		}
+
+		switch section.Kind {
+		case yaml.MappingNode:
+			mergeDefaultNode(section, def, sources)
+		case yaml.SequenceNode:
+			for _, item := range section.Content {
+				if item.Kind == yaml.MappingNode {
+					mergeDefaultNode(item, def, sources)
+				}
+			}
+		}
+	}
+
+	return defaults, nil
+}
+
+// mergeDefaultNode copy keys in def which are missing in item, values in item win
+func mergeDefaultNode(item, def *yaml.Node, sources configProvenance) {
+	for i := 0; i+1 < len(def.Content); i += 2 {
+		key, value := def.Content[i], def.Content[i+1]
+
+		_, v := mappingValue(item, key.Value)
+		switch {
+		case v == nil:
+			k, cp := copyNode(key), copyNode(value)
+			annotateDefaultNode(value, cp, sources)
+			item.Content = append(item.Content, k, cp)
+		case v.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeDefaultNode(v, value, sources)
+		}
+	}
+}
+
+// annotateDefaultNode record source of cp as default, with file and line of src which cp was copied from
+func annotateDefaultNode(src, cp *yaml.Node, sources configProvenance) {
+	res := &ConfigSource{Kind: ConfigSourceDefault, Line: src.Line}
+	if v, ok := sources[src]; ok {
+		res.File = v.File
+	}
+	sources[cp] = res
+
+	for i := range src.Content {
+		annotateDefaultNode(src.Content[i], cp.Content[i], sources)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBoot_WithDefaultsSection(t *testing.T) {
+	config := `
+defaults:
+  gin:
+    middleware:
+      logging:
+        enabled: true
+      meta:
+        enabled: true
+        prefix: rk
+    commonService:
+      enabled: true
+gin:
+  - name: greeter
+    port: 8080
+  - name: admin
+    port: 8081
+    middleware:
+      meta:
+        prefix: admin
+    commonService:
+      enabled: false
+`
+	boot, err := NewBootE(WithBootConfigRaw([]byte(config)))
+	assert.Nil(t, err)
+
+	// defaults section is removed
+	node, _ := lookupConfigNode(boot.config, "defaults")
+	assert.Nil(t, node)
+
+	// merged into every item
+	node, _ = lookupConfigNode(boot.config, "gin.greeter.middleware.logging.enabled")
+	assert.Equal(t, "true", node.Value)
+	node, _ = lookupConfigNode(boot.config, "gin.admin.middleware.logging.enabled")
+	assert.Equal(t, "true", node.Value)
+	node, _ = lookupConfigNode(boot.config, "gin.greeter.middleware.meta.prefix")
+	assert.Equal(t, "rk", node.Value)
+
+	// item-level values win
+	node, _ = lookupConfigNode(boot.config, "gin.admin.middleware.meta.prefix")
+	assert.Equal(t, "admin", node.Value)
+	node, _ = lookupConfigNode(boot.config, "gin.admin.middleware.meta.enabled")
+	assert.Equal(t, "true", node.Value)
+	node, _ = lookupConfigNode(boot.config, "gin.admin.commonService.enabled")
+	assert.Equal(t, "false", node.Value)
+
+	// provenance
+	res, _ := boot.ConfigSource("gin.greeter.middleware.logging.enabled")
+	assert.Equal(t, "default from <raw>:6", res.String())
+	res, _ = boot.ConfigSource("gin.admin.middleware.meta.prefix")
+	assert.Equal(t, "<raw>:19", res.String())
+
+	// items don't share nodes
+	node, _ = lookupConfigNode(boot.config, "gin.greeter.middleware.logging.enabled")
+	node.Value = "false"
+	node, _ = lookupConfigNode(boot.config, "gin.admin.middleware.logging.enabled")
+	assert.Equal(t, "true", node.Value)
+}
+
+func TestNewBoot_WithInvalidDefaultsSection(t *testing.T) {
+	_, err := NewBootE(WithBootConfigRaw([]byte("defaults: [gin]\n")))
+	assert.NotNil(t, err)
+
+	_, err = NewBootE(WithBootConfigRaw([]byte("defaults:\n  gin: [a]\n")))
+	assert.NotNil(t, err)
+
+	// when conditions are not allowed in defaults
+	_, err = NewBootE(WithBootConfigRaw([]byte("defaults:\n  gin:\n    when:\n      env: prod\n")))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "when is not allowed in defaults.gin at line 3")
+
+	// keys in defaults are validated even without items
+	_, err = NewBootE(
+		WithBootConfigRaw([]byte("defaults:\n  logger:\n    zap:\n      levle: info\n  loger: {}\n")),
+		WithStrictConfig())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `unknown key "levle" in defaults.logger.zap at line 4, did you mean "level"?`)
+	assert.Contains(t, err.Error(), `unknown key "loger" in defaults at line 5, did you mean "logger"?`)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dirDataLink is symlink which Kubernetes swaps atomically while updating mounted ConfigMaps and Secrets
+const dirDataLink = "..data"
+
+// DirOption is used as options of boot config directory source
+type DirOption func(*dirSource)
+
+// WithDirFileKey map content of plain file in directory onto path in boot config, like mysql.user-db.pass.
+//
+// Trailing newline of content would be trimmed and content would always be set as string, like passwords
+// which look like numbers. Use WithDirFileTypedKey for numbers and booleans.
+func WithDirFileKey(fileName, path string) DirOption {
+	return func(s *dirSource) {
+		s.keys[fileName] = path
+		delete(s.typed, fileName)
+	}
+}
+
+// WithDirFileTypedKey map content of plain file in directory onto path in boot config like WithDirFileKey,
+// single line content would keep its type like number and boolean, multiple lines content would be set as string.
+func WithDirFileTypedKey(fileName, path string) DirOption {
+	return func(s *dirSource) {
+		s.keys[fileName] = path
+		s.typed[fileName] = true
+	}
+}
+
+// WithBootConfigDir provide directory of boot config, like mounted Kubernetes ConfigMap or Secret.
+//
+// Every *.yaml file in directory would be merged into boot config in lexical order, latter one wins.
+// Plain files are ignored unless they are mapped with WithDirFileKey. Hidden files and directories
+// used by Kubernetes, like ..data, are skipped.
+//
+// With WithConfigWatch, swap of ..data symlink would trigger reloading.
+func WithBootConfigDir(dir string, opts ...DirOption) BootOption {
+	return func(boot *Boot) {
+		s := &dirSource{
+			dir:   dir,
+			keys:  map[string]string{},
+			typed: map[string]bool{},
+		}
+
+		for i := range opts {
+			opts[i](s)
+		}
+
+		boot.dirSource = s
+	}
+}
+
+// dirSource reads boot config from files in directory
+type dirSource struct {
+	dir   string
+	keys  map[string]string
+	typed map[string]bool
+}
+
+// read merge files in directory into boot config
+func (s *dirSource) read(loader *configLoader) (*yaml.Node, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, v := range entries {
+		// skip hidden files and directories maintained by Kubernetes, like ..data and ..2021_01_01_00_00_00.000
+		if strings.HasPrefix(v.Name(), ".") {
+			continue
+		}
+
+		// files in mounted directory are symlinks, follow them
+		info, err := os.Stat(filepath.Join(s.dir, v.Name()))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		names = append(names, v.Name())
+	}
+	sort.Strings(names)
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, name := range names {
+		if filepath.Ext(name) != ".yaml" {
+			continue
+		}
+
+		fragment, err := loader.readFragment(filepath.Join(s.dir, name), nil)
+		if err != nil {
+			return nil, err
+		}
+		mergeNode(root, fragment)
+	}
+
+	for _, name := range names {
+		path, ok := s.keys[name]
+		if !ok {
+			continue
+		}
+
+		filePath := filepath.Join(s.dir, name)
+		raw, err := loader.src.readFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		value := dirValueNode(strings.TrimRight(string(raw), "\r\n"), s.typed[name])
+		loader.sources.annotate(value, ConfigSource{Kind: ConfigSourceFile, File: filePath})
+		if err := setConfigValue(root, path, value); err != nil {
+			return nil, fmt.Errorf("failed to map %s onto boot config, %v", filePath, err)
+		}
+	}
+
+	return root, nil
+}
+
+// isChange returns true if event of name may change boot config in directory
+func (s *dirSource) isChange(name string) bool {
+	if filepath.Clean(filepath.Dir(name)) != filepath.Clean(s.dir) {
+		return false
+	}
+
+	base := filepath.Base(name)
+	if _, ok := s.keys[base]; ok {
+		return true
+	}
+
+	return base == dirDataLink || (!strings.HasPrefix(base, ".") && filepath.Ext(base) == ".yaml")
+}
+
+// dirValueNode returns node of content of plain file, type of single line content would be kept if typed is true
+func dirValueNode(value string, typed bool) *yaml.Node {
+	if typed && !strings.Contains(value, "\n") {
+		if node := overrideValueNode(value); node.Kind == yaml.ScalarNode {
+			return node
+		}
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Line: 1}
+}
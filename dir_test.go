@@ -0,0 +1,115 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigMapDir writes files into dir like Kubernetes does, with ..data symlink swapped atomically
+func writeConfigMapDir(t *testing.T, dir, version string, files map[string]string) {
+	dataDir := filepath.Join(dir, "..ut_"+version)
+	assert.Nil(t, os.MkdirAll(dataDir, 0755))
+
+	for name, content := range files {
+		assert.Nil(t, os.WriteFile(filepath.Join(dataDir, name), []byte(content), 0644))
+
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			assert.Nil(t, os.Symlink(filepath.Join(dirDataLink, name), link))
+		}
+	}
+
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	assert.Nil(t, os.Symlink(filepath.Base(dataDir), tmpLink))
+	assert.Nil(t, os.Rename(tmpLink, filepath.Join(dir, dirDataLink)))
+}
+
+func TestNewBoot_WithBootConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigMapDir(t, dir, "1", map[string]string{
+		"10-base.yaml":     "mysql:\n  - name: user-db\n    port: 3306\nredis:\n  - name: cache\n",
+		"20-override.yaml": "mysql:\n  - name: user-db\n    user: root\n    port: 3307\n",
+		"db-pass":          "my-pass\n",
+		"db-port":          "3308\n",
+		"tls.crt":          "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		"ignored.txt":      "ignored",
+	})
+
+	boot, err := NewBootE(WithBootConfigDir(dir,
+		WithDirFileKey("db-pass", "mysql[0].pass"),
+		WithDirFileKey("tls.crt", "mysql.user-db.caCert"),
+	))
+	assert.Nil(t, err)
+
+	// latter file wins
+	node, _ := lookupConfigNode(boot.config, "mysql.user-db.port")
+	assert.Equal(t, "3307", node.Value)
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.user")
+	assert.Equal(t, "root", node.Value)
+
+	// plain files
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "my-pass", node.Value)
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.caCert")
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----", node.Value)
+
+	node, _ = lookupConfigNode(boot.config, "redis.cache.name")
+	assert.Equal(t, "cache", node.Value)
+
+	res, _ := boot.ConfigSource("mysql.user-db.port")
+	assert.Equal(t, filepath.Join(dir, "20-override.yaml")+":4", res.String())
+	res, _ = boot.ConfigSource("mysql.user-db.pass")
+	assert.Equal(t, filepath.Join(dir, "db-pass")+":1", res.String())
+
+	// content would be string by default
+	boot, err = NewBootE(WithBootConfigDir(dir,
+		WithDirFileKey("db-port", "mysql.user-db.pass"),
+		WithDirFileTypedKey("db-port", "mysql.user-db.port"),
+		WithDirFileKey("db-port", "mysql.user-db.pass")))
+	assert.Nil(t, err)
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "!!str", node.Tag)
+	assert.Equal(t, "3308", node.Value)
+
+	// single line would keep type with typed key
+	boot, err = NewBootE(WithBootConfigDir(dir, WithDirFileTypedKey("db-port", "mysql.user-db.port")))
+	assert.Nil(t, err)
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.port")
+	assert.Equal(t, "!!int", node.Tag)
+
+	// with missing directory
+	_, err = NewBootE(WithBootConfigDir(filepath.Join(dir, "missing")))
+	assert.NotNil(t, err)
+}
+
+func TestNewBoot_WithBootConfigDirWatch(t *testing.T) {
+	defer func(v time.Duration) { watchDebounce = v }(watchDebounce)
+	watchDebounce = 10 * time.Millisecond
+
+	config := "utReload:\n  - name: ut-dir-a\n    enabled: true\n    value: "
+	dir := t.TempDir()
+	writeConfigMapDir(t, dir, "1", map[string]string{"boot.yaml": config + "a1\n"})
+
+	boot := NewBoot(WithBootConfigDir(dir), WithConfigWatch())
+	a := MustGetEntry[*utReloadEntry](boot, "ut-dir-a")
+
+	boot.Bootstrap(context.Background())
+	defer boot.stopWatch()
+
+	// swap of ..data symlink
+	writeConfigMapDir(t, dir, "2", map[string]string{"boot.yaml": config + "a2\n"})
+	assert.Eventually(t, func() bool {
+		value, _ := a.Values()
+		return value == "a2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
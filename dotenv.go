@@ -0,0 +1,182 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dotEnvKeyRegex matches valid keys in dotenv file
+var dotEnvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// WithDotEnv load dotenv files into environment before reading boot config.
+//
+// Variables already in environment would never be overridden, and files earlier in paths take
+// precedence over latter ones. Missing files are skipped, so that .env could be omitted in production.
+//
+// Loaded variables are visible to RK_ overrides, secret://env references and envPrefix of config entries.
+func WithDotEnv(paths ...string) BootOption {
+	return func(boot *Boot) {
+		boot.dotEnvPaths = append(boot.dotEnvPaths, paths...)
+	}
+}
+
+// dotEnvFile is dotenv file loaded and keys it supplied
+type dotEnvFile struct {
+	path string
+	keys []string
+}
+
+// loadDotEnv load dotenv files into boot.environ and os environment
+func (boot *Boot) loadDotEnv() ([]*dotEnvFile, error) {
+	res := make([]*dotEnvFile, 0)
+
+	for _, path := range boot.dotEnvPaths {
+		raw, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		vars, err := parseDotEnv(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s, %v", path, err)
+		}
+
+		file := &dotEnvFile{path: path, keys: make([]string, 0)}
+		for _, v := range vars {
+			if boot.lookupEnv(v[0]) {
+				continue
+			}
+
+			if err := os.Setenv(v[0], v[1]); err != nil {
+				return nil, err
+			}
+			boot.environ = append(boot.environ, v[0]+"="+v[1])
+			file.keys = append(file.keys, v[0])
+		}
+		res = append(res, file)
+	}
+
+	return res, nil
+}
+
+// lookupEnv returns true if key exists in boot.environ
+func (boot *Boot) lookupEnv(key string) bool {
+	for _, v := range boot.environ {
+		if strings.HasPrefix(v, key+"=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseDotEnv parse dotenv file into key value pairs in order of declaration.
+//
+// Bellow forms are supported:
+//
+//	# comment
+//	KEY=value # inline comment
+//	export KEY=value
+//	KEY="double quoted with \n escapes"
+//	KEY='single quoted literal'
+func parseDotEnv(raw []byte) ([][2]string, error) {
+	res := make([][2]string, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) < 1 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+
+		tokens := strings.SplitN(text, "=", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("expect KEY=value at line %d", line)
+		}
+
+		key := strings.TrimSpace(tokens[0])
+		if !dotEnvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("invalid key %q at line %d", key, line)
+		}
+
+		value, err := dotEnvValue(strings.TrimSpace(tokens[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s at line %d, %v", key, line, err)
+		}
+
+		res = append(res, [2]string{key, value})
+	}
+
+	return res, scanner.Err()
+}
+
+// dotEnvValue returns value with quotes and inline comment removed
+func dotEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := dotEnvQuoteEnd(value, '"')
+		if end < 1 {
+			return "", errors.New("unterminated double quote")
+		}
+		if err := dotEnvCheckTrailing(value[end+1:]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(value[:end+1])
+	case strings.HasPrefix(value, `'`):
+		end := dotEnvQuoteEnd(value, '\'')
+		if end < 1 {
+			return "", errors.New("unterminated single quote")
+		}
+		if err := dotEnvCheckTrailing(value[end+1:]); err != nil {
+			return "", err
+		}
+		return value[1:end], nil
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+// dotEnvQuoteEnd returns index of quote which closes the one at beginning of value, -1 if not found.
+// Escaped quotes are skipped in double quoted value, single quoted value is literal.
+func dotEnvQuoteEnd(value string, quote byte) int {
+	for i := 1; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && quote == '"':
+			i++
+		case value[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// dotEnvCheckTrailing returns error if anything other than spaces and comment follows closing quote
+func dotEnvCheckTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after closing quote", rest)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	raw := `
+# comment
+UT_PLAIN=value # inline comment
+export UT_EXPORT=exported
+UT_DOUBLE="line1\nline2 # not comment"
+UT_SINGLE='literal \n'
+UT_EMPTY=
+UT_COMMENT="x" # don't
+UT_ESCAPED="say \"hi\"" # it's "quoted"
+UT_SINGLE_COMMENT='y' # "comment"
+`
+	vars, err := parseDotEnv([]byte(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, [][2]string{
+		{"UT_PLAIN", "value"},
+		{"UT_EXPORT", "exported"},
+		{"UT_DOUBLE", "line1\nline2 # not comment"},
+		{"UT_SINGLE", `literal \n`},
+		{"UT_EMPTY", ""},
+		{"UT_COMMENT", "x"},
+		{"UT_ESCAPED", `say "hi"`},
+		{"UT_SINGLE_COMMENT", "y"},
+	}, vars)
+
+	// with invalid line
+	_, err = parseDotEnv([]byte("UT_PLAIN"))
+	assert.NotNil(t, err)
+
+	// with invalid key
+	_, err = parseDotEnv([]byte("1UT=value"))
+	assert.NotNil(t, err)
+
+	// with unterminated quote
+	_, err = parseDotEnv([]byte(`UT_DOUBLE="value`))
+	assert.NotNil(t, err)
+	_, err = parseDotEnv([]byte(`UT_DOUBLE="value\"`))
+	assert.NotNil(t, err)
+
+	// with characters after closing quote
+	_, err = parseDotEnv([]byte(`UT_DOUBLE="value" trailing`))
+	assert.NotNil(t, err)
+	_, err = parseDotEnv([]byte(`UT_SINGLE='value'trailing`))
+	assert.NotNil(t, err)
+}
+
+func TestNewBoot_WithDotEnv(t *testing.T) {
+	defer func() {
+		for _, v := range []string{"UT_DOTENV_PASS", "UT_DOTENV_USER", "RK_MYSQL__USER_DB__PORT"} {
+			os.Unsetenv(v)
+		}
+	}()
+
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ".env"),
+		[]byte("UT_DOTENV_PASS=env-pass\nUT_DOTENV_USER=dotenv-user\nRK_MYSQL__USER_DB__PORT=3307\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ".env.local"),
+		[]byte("UT_DOTENV_PASS=local-pass\nUT_DOTENV_HOST=local-host\n"), 0644))
+	defer os.Unsetenv("UT_DOTENV_HOST")
+
+	config := `
+mysql:
+  - name: user-db
+    user: secret://env/UT_DOTENV_USER
+    pass: secret://env/UT_DOTENV_PASS
+    port: 3306
+`
+
+	boot, err := NewBootE(
+		WithBootConfigRaw([]byte(config)),
+		WithDotEnv(filepath.Join(dir, ".env"), filepath.Join(dir, ".env.local"), filepath.Join(dir, "missing.env")),
+		func(boot *Boot) {
+			boot.environ = []string{"UT_DOTENV_USER=existing-user"}
+		})
+	assert.Nil(t, err)
+
+	// existing variables would not be overridden
+	node, _ := lookupConfigNode(boot.config, "mysql.user-db.user")
+	assert.Equal(t, "existing-user", node.Value)
+	_, ok := os.LookupEnv("UT_DOTENV_USER")
+	assert.False(t, ok)
+
+	// earlier file wins
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "env-pass", node.Value)
+	assert.Equal(t, "env-pass", os.Getenv("UT_DOTENV_PASS"))
+	assert.Equal(t, "local-host", os.Getenv("UT_DOTENV_HOST"))
+
+	// overrides
+	node, _ = lookupConfigNode(boot.config, "mysql.user-db.port")
+	assert.Equal(t, "3307", node.Value)
+
+	// keys supplied by each file
+	files, err := (&Boot{
+		dotEnvPaths: []string{filepath.Join(dir, ".env"), filepath.Join(dir, ".env.local")},
+		environ:     []string{"UT_DOTENV_USER=existing-user"},
+	}).loadDotEnv()
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, []string{"UT_DOTENV_PASS", "RK_MYSQL__USER_DB__PORT"}, files[0].keys)
+	assert.Equal(t, []string{"UT_DOTENV_HOST"}, files[1].keys)
+
+	// with invalid file
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ".env.invalid"), []byte("invalid"), 0644))
+	_, err = NewBootE(WithBootConfigRaw([]byte(config)), WithDotEnv(filepath.Join(dir, ".env.invalid")))
+	assert.NotNil(t, err)
+}
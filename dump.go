@@ -0,0 +1,243 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// printConfigFlag is command line flag of printing effective config and exit, value could be yaml or json,
+	// either as --rk.print-config=json or --rk.print-config json
+	printConfigFlag = "rk.print-config"
+	// redactedValue would replace values of sensitive keys in effective config
+	redactedValue = "******"
+)
+
+// DefaultRedactPatterns is regular expressions of keys whose values would be redacted in effective config.
+var DefaultRedactPatterns = []string{
+	`(?i)^pass$`,
+	`(?i)password$`,
+	`(?i)^token$`,
+	`(?i)secret`,
+	`(?i)privateKey$`,
+	`(?i)basicAuth$`,
+	`(?i)^basic$`,
+	`(?i)apiKey$`,
+}
+
+// WithRedactPatterns provide regular expressions of keys whose values would be redacted in effective config,
+// DefaultRedactPatterns would be replaced. No value would be redacted by key if no pattern provided.
+func WithRedactPatterns(patterns ...string) BootOption {
+	return func(boot *Boot) {
+		// nil means DefaultRedactPatterns, keep empty patterns as non-nil
+		boot.redactPatterns = append(make([]string, 0, len(patterns)), patterns...)
+	}
+}
+
+// EffectiveConfig returns boot config which reg funcs received, after all merging and overrides.
+//
+// Values of keys matching redact patterns would be redacted, see WithRedactPatterns for details.
+// Supported formats are ConfigFormatYAML and ConfigFormatJSON, source of values would be included
+// as comments in YAML format, see Boot.ConfigSource for details.
+func (boot *Boot) EffectiveConfig(format ConfigFormat) ([]byte, error) {
+	patterns, err := compileRedactPatterns(boot.redactPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if config, sources := boot.configSnapshot(); config != nil {
+		root = sources.copyNodeWithSource(config)
+		// anchored values aliased by secret keys, would be redacted wherever they appear
+		anchors := map[*yaml.Node]bool{}
+		redactedAnchors(config, patterns, false, anchors)
+		redactAnchors(config, root, anchors)
+	}
+	redactNode(root, patterns)
+
+	switch format {
+	case ConfigFormatYAML, "":
+		return yaml.Marshal(root)
+	case ConfigFormatJSON:
+		var v interface{}
+		if err := root.Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(v, "", "  ")
+	}
+
+	return nil, fmt.Errorf("unsupported effective config format %q", format)
+}
+
+// printConfigIfRequested print effective config into w if --rk.print-config flag was provided
+func (boot *Boot) printConfigIfRequested(w io.Writer) (bool, error) {
+	format, ok := "", false
+	for i := 0; i < len(boot.args); i++ {
+		arg := strings.TrimLeft(boot.args[i], "-")
+		switch {
+		case arg == printConfigFlag:
+			ok = true
+			// format could also be the next arg, like --rk.print-config json
+			if i+1 < len(boot.args) && isConfigFormatArg(boot.args[i+1]) {
+				i++
+				format = boot.args[i]
+			}
+		case strings.HasPrefix(arg, printConfigFlag+"="):
+			format, ok = strings.TrimPrefix(arg, printConfigFlag+"="), true
+		}
+	}
+
+	if !ok {
+		return false, nil
+	}
+
+	bytes, err := boot.EffectiveConfig(ConfigFormat(strings.ToLower(format)))
+	if err != nil {
+		return true, err
+	}
+
+	_, err = fmt.Fprintln(w, strings.TrimSpace(string(bytes)))
+	return true, err
+}
+
+// isConfigFormatArg returns true if arg is a format supported by --rk.print-config
+func isConfigFormatArg(arg string) bool {
+	switch ConfigFormat(strings.ToLower(arg)) {
+	case ConfigFormatYAML, ConfigFormatJSON:
+		return true
+	}
+
+	return false
+}
+
+// compileRedactPatterns compile patterns, DefaultRedactPatterns would be used if patterns is nil
+func compileRedactPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if patterns == nil {
+		patterns = DefaultRedactPatterns
+	}
+
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, v := range patterns {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q, %v", v, err)
+		}
+		res = append(res, re)
+	}
+
+	return res, nil
+}
+
+// redactNode replace scalar values of keys matching patterns
+func redactNode(node *yaml.Node, patterns []*regexp.Regexp) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			redactNode(item, patterns)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			matched := false
+			for _, re := range patterns {
+				matched = matched || re.MatchString(node.Content[i].Value)
+			}
+
+			if matched {
+				redactAll(node.Content[i+1])
+			} else {
+				redactNode(node.Content[i+1], patterns)
+			}
+		}
+	}
+}
+
+// redactedAnchors collect anchored nodes in node which are defined or aliased under keys matching patterns
+func redactedAnchors(node *yaml.Node, patterns []*regexp.Regexp, redacted bool, res map[*yaml.Node]bool) {
+	if redacted && len(node.Anchor) > 0 {
+		res[node] = true
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		if redacted && node.Alias != nil && !res[node.Alias] {
+			res[node.Alias] = true
+			// aliases in anchored node would be redacted as well
+			redactedAnchors(node.Alias, patterns, true, res)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			matched := redacted
+			for _, re := range patterns {
+				matched = matched || re.MatchString(node.Content[i].Value)
+			}
+			redactedAnchors(node.Content[i+1], patterns, matched, res)
+		}
+	default:
+		for _, v := range node.Content {
+			redactedAnchors(v, patterns, redacted, res)
+		}
+	}
+}
+
+// redactAnchors redact values of dst, which is a copy of src, whose origins are in anchors
+func redactAnchors(src, dst *yaml.Node, anchors map[*yaml.Node]bool) {
+	for src.Kind == yaml.AliasNode && src.Alias != nil {
+		src = src.Alias
+	}
+
+	if anchors[src] {
+		redactAll(dst)
+		return
+	}
+
+	for i := range src.Content {
+		if i < len(dst.Content) {
+			redactAnchors(src.Content[i], dst.Content[i], anchors)
+		}
+	}
+}
+
+// redactAll replace all non-empty scalar values in node
+func redactAll(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		if len(node.Value) > 0 && node.Tag != "!!null" {
+			node.Tag, node.Style, node.Value = "!!str", 0, redactedValue
+		}
+		return
+	}
+
+	for _, v := range node.Content {
+		redactAll(v)
+	}
+}
+
+// copyNode returns deep copy of node, aliases are replaced by copies of anchored nodes, so that the copy would
+// never share nodes with the original one, and values reached by aliases would be redacted as well
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return copyNode(node.Alias)
+	}
+
+	res := *node
+	res.Anchor = ""
+	res.Content = make([]*yaml.Node, len(node.Content))
+	for i := range node.Content {
+		res.Content[i] = copyNode(node.Content[i])
+	}
+
+	return &res
+}
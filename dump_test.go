@@ -0,0 +1,181 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dumpConfig = `
+mysql:
+  - name: user-db
+    user: root
+    pass: my-pass
+gin:
+  - name: greeter
+    port: 8080
+    middleware:
+      auth:
+        basic: ["user:pass"]
+      jwt:
+        tokenLookup: "header:Authorization"
+        asymmetric:
+          privateKey: my-key
+          privateKeyPath: key.pem
+`
+
+func TestBoot_EffectiveConfig(t *testing.T) {
+	boot := NewBoot(WithBootConfigRaw([]byte(dumpConfig)))
+
+	res, err := boot.EffectiveConfig(ConfigFormatYAML)
+	assert.Nil(t, err)
+	assert.Equal(t, `mysql:
+    - name: user-db # <raw>:3
+      user: root # <raw>:4
+      pass: '******' # <raw>:5
+gin:
+    - name: greeter # <raw>:7
+      port: 8080 # <raw>:8
+      middleware:
+        auth:
+            basic:
+                - '******' # <raw>:11
+        jwt:
+            tokenLookup: "header:Authorization" # <raw>:13
+            asymmetric:
+                privateKey: '******' # <raw>:15
+                privateKeyPath: key.pem # <raw>:16
+`, string(res))
+
+	res, err = boot.EffectiveConfig(ConfigFormatJSON)
+	assert.Nil(t, err)
+	assert.Contains(t, string(res), `"pass": "******"`)
+	assert.Contains(t, string(res), `"port": 8080`)
+
+	// values in boot config would not be changed
+	node, _ := lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "my-pass", node.Value)
+
+	// unsupported format
+	_, err = boot.EffectiveConfig(ConfigFormatTOML)
+	assert.NotNil(t, err)
+}
+
+func TestBoot_EffectiveConfig_WithRedactPatterns(t *testing.T) {
+	boot := NewBoot(
+		WithBootConfigRaw([]byte(dumpConfig)),
+		WithRedactPatterns(`(?i)^user$`))
+
+	res, err := boot.EffectiveConfig(ConfigFormatYAML)
+	assert.Nil(t, err)
+	assert.Contains(t, string(res), "user: '******'")
+	assert.Contains(t, string(res), "pass: my-pass")
+
+	// invalid pattern
+	boot.redactPatterns = []string{"("}
+	_, err = boot.EffectiveConfig(ConfigFormatYAML)
+	assert.NotNil(t, err)
+}
+
+func TestBoot_EffectiveConfig_WithoutRedactPatterns(t *testing.T) {
+	boot := NewBoot(
+		WithBootConfigRaw([]byte(dumpConfig)),
+		WithRedactPatterns())
+
+	assert.NotNil(t, boot.redactPatterns)
+	res, err := boot.EffectiveConfig(ConfigFormatYAML)
+	assert.Nil(t, err)
+	assert.Contains(t, string(res), "pass: my-pass")
+	assert.Contains(t, string(res), "privateKey: my-key")
+	assert.NotContains(t, string(res), "******")
+}
+
+func TestBoot_EffectiveConfig_WithAlias(t *testing.T) {
+	config := `
+shared:
+  dbpw: &pw hunter2
+  db: &db
+    user: root
+    pass: my-pass
+mysql:
+  - name: db
+    password: *pw
+  - name: other-db
+    conn: *db
+redis:
+  - name: cache
+    password: &redisPw my-redis-pass
+    label: *redisPw
+`
+	boot := NewBoot(WithBootConfigRaw([]byte(config)))
+
+	for _, format := range []ConfigFormat{ConfigFormatYAML, ConfigFormatJSON} {
+		res, err := boot.EffectiveConfig(format)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(res), "hunter2")
+		assert.NotContains(t, string(res), "my-pass")
+		assert.NotContains(t, string(res), "my-redis-pass")
+		assert.NotContains(t, string(res), "*pw")
+		assert.Contains(t, string(res), "root")
+	}
+
+	res, _ := boot.EffectiveConfig(ConfigFormatJSON)
+	assert.Contains(t, string(res), `"password": "******"`)
+
+	// values in boot config would not be changed
+	node, _ := lookupConfigNode(boot.config, "shared.dbpw")
+	assert.Equal(t, "hunter2", node.Value)
+}
+
+func TestBoot_PrintConfigIfRequested(t *testing.T) {
+	boot := NewBoot(WithBootConfigRaw([]byte(dumpConfig)))
+	buf := &bytes.Buffer{}
+
+	// flag missing
+	printed, err := boot.printConfigIfRequested(buf)
+	assert.False(t, printed)
+	assert.Nil(t, err)
+	assert.Empty(t, buf.String())
+
+	boot.args = []string{"--rk.print-config=json"}
+	printed, err = boot.printConfigIfRequested(buf)
+	assert.True(t, printed)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"pass": "******"`)
+
+	// format as the next arg
+	buf.Reset()
+	boot.args = []string{"--rk.print-config", "json"}
+	printed, err = boot.printConfigIfRequested(buf)
+	assert.True(t, printed)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"pass": "******"`)
+
+	// next arg which is not a format is left alone
+	buf.Reset()
+	boot.args = []string{"--rk.print-config", "--other"}
+	printed, err = boot.printConfigIfRequested(buf)
+	assert.True(t, printed)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "pass: '******'")
+
+	// NewBootE would exit after printing
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = os.Exit
+	}()
+	NewBoot(WithBootConfigRaw([]byte(dumpConfig)), func(boot *Boot) {
+		boot.args = []string{"--rk.print-config"}
+	})
+	assert.Equal(t, 0, exitCode)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+)
+
+// GetEntry returns entry with Go type of T and name.
+//
+// Entries bootstrapped by boot would be searched first, and then entries in rkentry.GlobalAppCtx.
+// boot could be nil, in that case, only rkentry.GlobalAppCtx would be searched.
+//
+// If T is an interface like rkentry.Entry and more than one entry with the name could be asserted as T,
+// like entries of different types sharing the same name, error would be returned.
+//
+// Example:
+//
+//	ginEntry, err := rkboot.GetEntry[*rkgin.GinEntry](boot, "greeter")
+func GetEntry[T rkentry.Entry](boot *Boot, name string) (T, error) {
+	var zero T
+
+	candidates := listEntriesOfType[T](boot)
+	if list := candidates[name]; len(list) == 1 {
+		return list[0], nil
+	} else if len(list) > 1 {
+		types := make([]string, 0, len(list))
+		for _, e := range list {
+			types = append(types, e.GetType())
+		}
+		sort.Strings(types)
+
+		return zero, fmt.Errorf("entry %q of type %s is ambiguous, found in entry types: %v",
+			name, reflect.TypeOf((*T)(nil)).Elem(), types)
+	}
+
+	names := make([]string, 0, len(candidates))
+	for k := range candidates {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return zero, fmt.Errorf("entry %q of type %s not found, available entries of this type: %v",
+		name, reflect.TypeOf((*T)(nil)).Elem(), names)
+}
+
+// MustGetEntry returns entry with Go type of T and name, panic if missing or ambiguous.
+func MustGetEntry[T rkentry.Entry](boot *Boot, name string) T {
+	res, err := GetEntry[T](boot, name)
+	if err != nil {
+		rkentry.ShutdownWithError(err)
+	}
+
+	return res
+}
+
+// listEntriesOfType list distinct entries which could be asserted as T, keyed by entry name
+func listEntriesOfType[T rkentry.Entry](boot *Boot) map[string][]T {
+	res := map[string][]T{}
+	// the same entry is usually registered in both boot and rkentry.GlobalAppCtx
+	seen := map[string]bool{}
+
+	add := func(m map[string]map[string]rkentry.Entry) {
+		for _, byEntryName := range m {
+			for _, e := range byEntryName {
+				v, ok := e.(T)
+				if !ok {
+					continue
+				}
+
+				key := fmt.Sprintf("%s/%s/%p", e.GetType(), e.GetName(), e)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				res[v.GetName()] = append(res[v.GetName()], v)
+			}
+		}
+	}
+
+	if boot != nil {
+		add(boot.pluginEntries)
+		add(boot.userEntries)
+		add(boot.webEntries)
+	}
+	add(rkentry.GlobalAppCtx.ListEntries())
+
+	return res
+}
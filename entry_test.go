@@ -0,0 +1,67 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEntry(t *testing.T) {
+	entry := RegisterMyEntry(WithName("ut-get"))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// happy case
+	res, err := GetEntry[*MyEntry](nil, "ut-get")
+	assert.Nil(t, err)
+	assert.Equal(t, entry, res)
+
+	// missing entry, error should list available entries
+	res, err = GetEntry[*MyEntry](nil, "ut-missing")
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ut-missing")
+	assert.Contains(t, err.Error(), "ut-get")
+
+	// entry of another Go type
+	_, err = GetEntry[*rkentry.CertEntry](nil, "ut-get")
+	assert.NotNil(t, err)
+}
+
+func TestMustGetEntry(t *testing.T) {
+	entry := RegisterMyEntry(WithName("ut-must-get"))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	boot := &Boot{}
+	assert.Equal(t, entry, MustGetEntry[*MyEntry](boot, "ut-must-get"))
+
+	defer assertPanic(t)
+	MustGetEntry[*MyEntry](boot, "ut-missing")
+}
+
+func TestGetEntry_Ambiguous(t *testing.T) {
+	entry := RegisterMyEntry(WithName("ut-ambiguous"))
+	defer rkentry.GlobalAppCtx.RemoveEntry(entry)
+	other := &MyEntry{EntryName: "ut-ambiguous", EntryType: "otherEntry"}
+	rkentry.GlobalAppCtx.AddEntry(other)
+	defer rkentry.GlobalAppCtx.RemoveEntry(other)
+
+	// the same entry in boot and GlobalAppCtx is not ambiguous
+	boot := &Boot{userEntries: map[string]map[string]rkentry.Entry{"myEntry": {"ut-ambiguous": entry}}}
+	res, err := GetEntry[*MyEntry](boot, "ut-ambiguous")
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ambiguous")
+	assert.Contains(t, err.Error(), "[myEntry otherEntry]")
+
+	_, err = GetEntry[rkentry.Entry](nil, "ut-ambiguous")
+	assert.NotNil(t, err)
+
+	defer assertPanic(t)
+	MustGetEntry[rkentry.Entry](boot, "ut-ambiguous")
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey is directive for composing boot config from fragments.
+//
+// Value could be a path or list of paths, relative to the including file. Keys in fragments would be
+// deep merged into the mapping which contains the directive, later fragments override earlier ones and
+// keys declared beside the directive override all fragments.
+//
+// Example:
+//
+//	---
+//	$include: fragments/logger.yaml
+//	gin:
+//	  - $include: fragments/gin-middleware.yaml
+//	    name: greeter
+//	    port: 8080
+const includeKey = "$include"
+
+// resolveIncludes resolve include directives in node recursively, chain is list of files including node
+func (l *configLoader) resolveIncludes(node *yaml.Node, chain []string) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := l.resolveIncludes(item, chain); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		fragments := make([]string, 0)
+		content := make([]*yaml.Node, 0, len(node.Content))
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value != includeKey {
+				content = append(content, key, value)
+				continue
+			}
+
+			var paths []string
+			if value.Kind == yaml.ScalarNode {
+				paths = []string{value.Value}
+			} else if err := value.Decode(&paths); err != nil {
+				return fmt.Errorf("invalid %s at line %d in %s, expect path or list of paths",
+					includeKey, key.Line, chain[len(chain)-1])
+			}
+			fragments = append(fragments, paths...)
+		}
+		node.Content = content
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := l.resolveIncludes(node.Content[i+1], chain); err != nil {
+				return err
+			}
+		}
+
+		if len(fragments) < 1 {
+			return nil
+		}
+
+		merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, v := range fragments {
+			fragment, err := l.readFragment(l.src.join(chain[len(chain)-1], v), chain)
+			if err != nil {
+				return err
+			}
+			mergeNode(merged, fragment)
+		}
+
+		mergeNode(merged, node)
+		node.Content = merged.Content
+	}
+
+	return nil
+}
+
+// readFragment read file of name and resolve includes in it
+func (l *configLoader) readFragment(name string, chain []string) (*yaml.Node, error) {
+	for _, v := range chain {
+		if v == name {
+			return nil, fmt.Errorf("include cycle detected, %s -> %s", strings.Join(chain, " -> "), name)
+		}
+	}
+	chain = append(append([]string{}, chain...), name)
+
+	raw, err := l.src.readFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, include chain: %s, %v", name, strings.Join(chain, " -> "), err)
+	}
+
+	root, err := parseConfig(raw, configFormatOf(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s, include chain: %s, %v", name, strings.Join(chain, " -> "), err)
+	}
+	l.sources.annotate(root, ConfigSource{Kind: ConfigSourceFile, File: name})
+
+	if err := l.resolveIncludes(root, chain); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/boot.yaml": &fstest.MapFile{Data: []byte(`
+$include: fragments/logger.yaml
+gin:
+  - $include: [fragments/middleware.yaml, fragments/middleware-override.json]
+    name: greeter
+    port: 8080
+    middleware:
+      meta:
+        prefix: ut
+`)},
+		"conf/fragments/logger.yaml": &fstest.MapFile{Data: []byte(`
+$include: event.yaml
+logger:
+  - name: my-logger
+`)},
+		"conf/fragments/event.yaml": &fstest.MapFile{Data: []byte(`
+event:
+  - name: my-event
+`)},
+		"conf/fragments/middleware.yaml": &fstest.MapFile{Data: []byte(`
+port: 1949
+middleware:
+  logging:
+    enabled: true
+  meta:
+    enabled: true
+    prefix: rk
+`)},
+		"conf/fragments/middleware-override.json": &fstest.MapFile{Data: []byte(`{"middleware": {"logging": {"enabled": false}}}`)},
+	}
+
+	root, err := newConfigLoader(fsSource{fsys: fsys}).readFragment("conf/boot.yaml", []string{})
+	assert.Nil(t, err)
+
+	expected := map[string]interface{}{}
+	assert.Nil(t, yaml.Unmarshal([]byte(`
+logger:
+  - name: my-logger
+event:
+  - name: my-event
+gin:
+  - name: greeter
+    port: 8080
+    middleware:
+      logging:
+        enabled: false
+      meta:
+        enabled: true
+        prefix: ut
+`), &expected))
+
+	actual := map[string]interface{}{}
+	assert.Nil(t, root.Decode(&actual))
+	assert.Equal(t, expected, actual)
+}
+
+func TestResolveIncludes_WithCycle(t *testing.T) {
+	fsys := fstest.MapFS{
+		"boot.yaml": &fstest.MapFile{Data: []byte("$include: a.yaml")},
+		"a.yaml":    &fstest.MapFile{Data: []byte("$include: b.yaml")},
+		"b.yaml":    &fstest.MapFile{Data: []byte("$include: a.yaml")},
+	}
+
+	_, err := newConfigLoader(fsSource{fsys: fsys}).readFragment("boot.yaml", []string{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "include cycle detected, boot.yaml -> a.yaml -> b.yaml -> a.yaml")
+}
+
+func TestResolveIncludes_WithInvalidFragment(t *testing.T) {
+	fsys := fstest.MapFS{
+		"boot.yaml": &fstest.MapFile{Data: []byte("$include: a.yaml")},
+		"a.yaml":    &fstest.MapFile{Data: []byte("$include: [b.yaml]")},
+		"b.yaml":    &fstest.MapFile{Data: []byte("logger: [")},
+	}
+
+	_, err := newConfigLoader(fsSource{fsys: fsys}).readFragment("boot.yaml", []string{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to parse b.yaml, include chain: boot.yaml -> a.yaml -> b.yaml")
+
+	// missing fragment
+	delete(fsys, "b.yaml")
+	_, err = newConfigLoader(fsSource{fsys: fsys}).readFragment("boot.yaml", []string{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to read b.yaml")
+
+	// invalid directive
+	fsys["a.yaml"] = &fstest.MapFile{Data: []byte("$include: {b: c}")}
+	_, err = newConfigLoader(fsSource{fsys: fsys}).readFragment("boot.yaml", []string{})
+	assert.NotNil(t, err)
+}
+
+func TestNewBoot_WithInclude(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "boot.yaml"), []byte("$include: entry.yaml"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "entry.yaml"), []byte("myEntry:\n  name: ut-include\n  enabled: true"), 0644))
+
+	boot := NewBoot(WithBootConfigPath(filepath.Join(dir, "boot.yaml"), nil))
+	assert.NotNil(t, boot.userEntries["myEntry"]["ut-include"])
+	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-include"))
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// printSchemaFlag is command line flag of printing JSON Schema of boot config and exit
+	printSchemaFlag = "rk.print-schema"
+	// jsonSchemaDraft is version of JSON Schema which is supported by most of editors
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+// durationType is reflect type of time.Duration
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ConfigJSONSchema returns JSON Schema of boot config.
+//
+// Sections are generated from schemas declared with RegisterConfigSchema, including entries registered
+// with RegisterUserEntry. Web frames like gin and grpc should declare config type of their entries which they
+// export with RegisterConfigSchema, fields shared by web frames like port and middleware would be documented
+// if desc tags are missing. Sections without declared schema accept any object or list.
+//
+// Bellow struct tags are used while generating schema of fields:
+//
+//	yaml:     name of field
+//	desc:     description of field
+//	default:  default value of field
+//	validate: required, min, max and oneof rules, see Boot.UnmarshalKey for details
+func ConfigJSONSchema() ([]byte, error) {
+	keys, _ := configKeys()
+	return configJSONSchema(keys, webFrameKeys())
+}
+
+// configJSONSchema returns JSON Schema of boot config with top level keys, webKeys is keys owned by web frames
+func configJSONSchema(keys []string, webKeys map[string]bool) ([]byte, error) {
+	properties := map[string]interface{}{
+		includeKey: map[string]interface{}{
+			"description": "Files merged into boot config, relative to the file declares them",
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+	defaults := map[string]interface{}{}
+
+	for _, key := range keys {
+		t, _ := configSchema(key)
+		if t == nil {
+			t = builtInSchemaTypes[key]
+		}
+
+		item := map[string]interface{}{"type": "object"}
+		if t != nil {
+			item = typeSchema(t, map[reflect.Type]bool{})
+			docs := builtInSchemaDocs[key]
+			if webKeys[key] {
+				docs = mergeSchemaDocs(webFrameSchemaDocs(), docs)
+			}
+			applySchemaDocs(item, docs)
+		}
+
+		// defaults section shares schema of items without required fields
+		def := copySchema(item)
+		delete(def, "required")
+		defaults[key] = def
+
+		if props, ok := item["properties"].(map[string]interface{}); ok {
+			props[whenKey] = whenSchema()
+		}
+
+		properties[key] = map[string]interface{}{
+			"description": fmt.Sprintf("Boot config of %s, a single object or a list of objects", key),
+			"oneOf": []interface{}{
+				item,
+				map[string]interface{}{"type": "array", "items": item},
+			},
+		}
+	}
+
+	properties[defaultsKey] = map[string]interface{}{
+		"description": "Values deep merged into every item of a type, item-level values win",
+		"type":        "object",
+		"properties":  defaults,
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":     jsonSchemaDraft,
+		"title":       "boot.yaml",
+		"description": "Boot config of rk-boot",
+		"type":        "object",
+		"properties":  properties,
+	}, "", "  ")
+}
+
+// printSchemaIfRequested print JSON Schema of boot config into w if --rk.print-schema flag was provided
+func (boot *Boot) printSchemaIfRequested(w io.Writer) (bool, error) {
+	ok := false
+	for _, arg := range boot.args {
+		ok = ok || strings.TrimLeft(arg, "-") == printSchemaFlag
+	}
+
+	if !ok {
+		return false, nil
+	}
+
+	bytes, err := ConfigJSONSchema()
+	if err != nil {
+		return true, err
+	}
+
+	_, err = fmt.Fprintln(w, string(bytes))
+	return true, err
+}
+
+// typeSchema returns JSON Schema of type, types in stack would be treated as any to avoid infinite recursion
+func typeSchema(t reflect.Type, stack map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return map[string]interface{}{"type": []string{"string", "integer"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), stack)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), stack)}
+	case reflect.Struct:
+		if stack[t] {
+			return map[string]interface{}{}
+		}
+		stack[t] = true
+		defer delete(stack, t)
+
+		properties := map[string]interface{}{}
+		required := make([]string, 0)
+		structSchema(t, stack, properties, &required)
+
+		res := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			sort.Strings(required)
+			res["required"] = required
+		}
+		return res
+	}
+
+	// interface and others accept any value
+	return map[string]interface{}{}
+}
+
+// structSchema fill properties and required fields of struct, fields of inline structs are flattened
+func structSchema(t reflect.Type, stack map[reflect.Type]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline, skip := yamlFieldName(field)
+		if skip {
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if (inline || (field.Anonymous && len(field.Tag.Get("yaml")) < 1)) && ft.Kind() == reflect.Struct {
+			structSchema(ft, stack, properties, required)
+			continue
+		}
+
+		schema := typeSchema(field.Type, stack)
+		if desc, ok := field.Tag.Lookup("desc"); ok {
+			schema["description"] = desc
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			schema["default"] = defaultValue(def)
+		}
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			applyRuleSchema(schema, ft, rule, name, required)
+		}
+
+		properties[name] = schema
+	}
+}
+
+// applyRuleSchema convert validate rule into keywords of JSON Schema
+func applyRuleSchema(schema map[string]interface{}, t reflect.Type, rule, name string, required *[]string) {
+	ruleName, param := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		ruleName, param = rule[:i], rule[i+1:]
+	}
+
+	limit, _ := strconv.ParseFloat(param, 64)
+	switch ruleName {
+	case "required":
+		*required = append(*required, name)
+	case "min", "max":
+		keyword := map[reflect.Kind]string{reflect.String: "Length", reflect.Slice: "Items", reflect.Map: "Properties"}[t.Kind()]
+		if len(keyword) > 0 {
+			schema[ruleName+keyword] = int(limit)
+		} else if ruleName == "min" {
+			schema["minimum"] = limit
+		} else {
+			schema["maximum"] = limit
+		}
+	case "oneof":
+		enum := make([]interface{}, 0)
+		for _, v := range strings.Fields(param) {
+			enum = append(enum, defaultValue(v))
+		}
+		schema["enum"] = enum
+	}
+}
+
+// defaultValue parse value of default tag as YAML, so that numbers and booleans keep their types
+func defaultValue(value string) interface{} {
+	var res interface{}
+	if err := yaml.Unmarshal([]byte(value), &res); err != nil || res == nil {
+		return value
+	}
+
+	return res
+}
+
+// whenSchema returns JSON Schema of when conditions of items
+func whenSchema() map[string]interface{} {
+	list := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+
+	return map[string]interface{}{
+		"description": "Conditions of the item, it would be removed if any of them is not matched",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"profile":  list,
+			"hostname": list,
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// copySchema returns deep copy of schema, so that changing copy would never affect schema
+func copySchema(schema map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		res[k] = copySchemaValue(v)
+	}
+
+	return res
+}
+
+// copySchemaValue returns deep copy of maps and lists in value
+func copySchemaValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copySchema(v)
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = copySchemaValue(v[i])
+		}
+		return res
+	case []string:
+		return append([]string{}, v...)
+	}
+
+	return value
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"reflect"
+	"strings"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+)
+
+// schemaDoc is description and default value of field in built-in sections whose types have no desc tags
+type schemaDoc struct {
+	desc string
+	def  string
+}
+
+var (
+	// builtInSchemaTypes is schema of sections used while generating JSON Schema only
+	builtInSchemaTypes = map[string]reflect.Type{
+		"app": reflect.TypeOf(bootAppSchema{}),
+	}
+
+	// builtInSchemaDocs is docs of fields in built-in sections keyed by top level key and path of field
+	builtInSchemaDocs = map[string]map[string]*schemaDoc{
+		"logger": mergeSchemaDocs(entrySchemaDocs("logger"), map[string]*schemaDoc{
+			"zap":                                {desc: "Config of zap logger"},
+			"zap.level":                          {desc: "Minimum enabled logging level", def: "info"},
+			"zap.development":                    {desc: "Put logger in development mode", def: "true"},
+			"zap.disableCaller":                  {desc: "Stop annotating logs with caller", def: "false"},
+			"zap.disableStacktrace":              {desc: "Disable automatic stacktrace capturing", def: "true"},
+			"zap.encoding":                       {desc: "Encoding of logger, console or json", def: "console"},
+			"zap.outputPaths":                    {desc: "Paths of logging output", def: "[stdout]"},
+			"zap.errorOutputPaths":               {desc: "Paths of internal logger errors", def: "[stderr]"},
+			"zap.encoderConfig.timeKey":          {desc: "Key of time", def: "ts"},
+			"zap.encoderConfig.levelKey":         {desc: "Key of level", def: "level"},
+			"zap.encoderConfig.nameKey":          {desc: "Key of logger name", def: "logger"},
+			"zap.encoderConfig.callerKey":        {desc: "Key of caller", def: "caller"},
+			"zap.encoderConfig.messageKey":       {desc: "Key of message", def: "msg"},
+			"zap.encoderConfig.stacktraceKey":    {desc: "Key of stacktrace", def: "stacktrace"},
+			"zap.encoderConfig.skipLineEnding":   {desc: "Skip line ending", def: "false"},
+			"zap.encoderConfig.lineEnding":       {desc: "Line ending", def: `"\n"`},
+			"zap.encoderConfig.consoleSeparator": {desc: "Separator of console encoding", def: `"\t"`},
+			"zap.sampling":                       {desc: "Sampling policy, sampling is disabled if missing"},
+			"zap.initialFields":                  {desc: "Fields added into every log"},
+		}),
+		"event": mergeSchemaDocs(entrySchemaDocs("event"), map[string]*schemaDoc{
+			"encoding":    {desc: "Encoding of event, console or json", def: "console"},
+			"outputPaths": {desc: "Paths of event output", def: "[stdout]"},
+		}),
+		"cert": mergeSchemaDocs(entrySchemaDocs("cert"), map[string]*schemaDoc{
+			"caPath":      {desc: "Path of CA file"},
+			"certPemPath": {desc: "Path of cert PEM file"},
+			"keyPemPath":  {desc: "Path of key PEM file"},
+		}),
+		"config": mergeSchemaDocs(entrySchemaDocs("config"), map[string]*schemaDoc{
+			"path":      {desc: "Path of config file"},
+			"envPrefix": {desc: "Prefix of environment variables overriding config"},
+			"content":   {desc: "Values of config"},
+		}),
+	}
+)
+
+// entrySchemaDocs returns docs of fields shared by built-in entries, lumberjack and loki are declared by
+// logger and event only
+func entrySchemaDocs(key string) map[string]*schemaDoc {
+	res := map[string]*schemaDoc{
+		"name":        {desc: "Required, name of " + key + " entry"},
+		"description": {desc: "Description of entry"},
+		"domain":      {desc: "Domain of entry, entry would be ignored if not matching DOMAIN environment variable", def: "*"},
+	}
+
+	if key != "logger" && key != "event" {
+		return res
+	}
+
+	return mergeSchemaDocs(res, map[string]*schemaDoc{
+		"default":                 {desc: "Use as default " + key + " entry", def: "false"},
+		"lumberjack":              {desc: "Rotation of log files, files would not be rotated if missing"},
+		"lumberjack.filename":     {desc: "Path of log file"},
+		"lumberjack.maxsize":      {desc: "Max size of log file in MB, suggested: 1024"},
+		"lumberjack.maxage":       {desc: "Max days to retain old log files, suggested: 7"},
+		"lumberjack.maxbackups":   {desc: "Max number of old log files, suggested: 3"},
+		"lumberjack.localtime":    {desc: "Use local time in names of backup files, suggested: true"},
+		"lumberjack.compress":     {desc: "Compress backup files, suggested: true"},
+		"loki":                    {desc: "Ship logs to loki"},
+		"loki.enabled":            {desc: "Enable loki", def: "false"},
+		"loki.addr":               {desc: "Address of loki", def: "localhost:3100"},
+		"loki.path":               {desc: "Path of push API", def: "/loki/api/v1/push"},
+		"loki.username":           {desc: "Username of basic auth"},
+		"loki.password":           {desc: "Password of basic auth"},
+		"loki.maxBatchWaitMs":     {desc: "Max wait time of batch in milliseconds", def: "3000"},
+		"loki.maxBatchSize":       {desc: "Max size of batch", def: "1000"},
+		"loki.insecureSkipVerify": {desc: "Skip verifying certificate of loki", def: "false"},
+		"loki.labels":             {desc: "Labels attached to logs"},
+	})
+}
+
+// mergeSchemaDocs returns docs of both, docs in b win
+func mergeSchemaDocs(a, b map[string]*schemaDoc) map[string]*schemaDoc {
+	res := map[string]*schemaDoc{}
+	for k, v := range a {
+		res[k] = v
+	}
+	for k, v := range b {
+		res[k] = v
+	}
+
+	return res
+}
+
+// applySchemaDocs fill description and default of fields in schema of item, fields missing in schema are ignored
+func applySchemaDocs(schema map[string]interface{}, docs map[string]*schemaDoc) {
+	for path, doc := range docs {
+		field := schema
+		for _, name := range strings.Split(path, ".") {
+			props, _ := field["properties"].(map[string]interface{})
+			field, _ = props[name].(map[string]interface{})
+			if field == nil {
+				break
+			}
+		}
+
+		if field == nil {
+			continue
+		}
+
+		if _, ok := field["description"]; !ok && len(doc.desc) > 0 {
+			field["description"] = doc.desc
+		}
+		if _, ok := field["default"]; !ok && len(doc.def) > 0 {
+			field["default"] = defaultValue(doc.def)
+		}
+	}
+}
+
+// webFrameKeys returns keys declared by web frame reg funcs registered into rkentry, like gin and grpc
+func webFrameKeys() map[string]bool {
+	return regFuncDeclaredKeys(rkentry.ListWebFrameEntryRegFunc())
+}
+
+// bootAppSchema is schema of app section
+type bootAppSchema struct {
+	Name        string   `yaml:"name" desc:"Name of application" default:"rk-app"`
+	Version     string   `yaml:"version" desc:"Version of application" default:"v0.0.0"`
+	Description string   `yaml:"description" desc:"Description of application"`
+	Keywords    []string `yaml:"keywords" desc:"Keywords of application"`
+	HomeUrl     string   `yaml:"homeUrl" desc:"Home page of application"`
+	DocsUrl     []string `yaml:"docsUrl" desc:"Documents of application"`
+	Maintainers []string `yaml:"maintainers" desc:"Maintainers of application"`
+}
+
+// webFrameSchemaDocs returns docs of fields shared by web frames like gin and grpc, which would be applied to
+// schemas declared by web frames with RegisterConfigSchema, fields missing in schema of web frame are ignored
+func webFrameSchemaDocs() map[string]*schemaDoc {
+	res := map[string]*schemaDoc{
+		"name":                                 {desc: "Required, name of entry"},
+		"description":                          {desc: "Description of entry"},
+		"enabled":                              {desc: "Enable entry", def: "false"},
+		"port":                                 {desc: "Port of server, 0 means random port"},
+		"certEntry":                            {desc: "Reference of cert entry, TLS is disabled if missing"},
+		"loggerEntry":                          {desc: "Reference of logger entry, STDOUT would be used if missing"},
+		"eventEntry":                           {desc: "Reference of event entry, STDOUT would be used if missing"},
+		"sw.enabled":                           {desc: "Enable swagger UI", def: "false"},
+		"sw.path":                              {desc: "Path of swagger UI", def: "sw"},
+		"sw.jsonPaths":                         {desc: "Paths of swagger JSON files"},
+		"sw.headers":                           {desc: "Headers added into responses, like sw:rk"},
+		"docs.enabled":                         {desc: "Enable API docs UI", def: "false"},
+		"docs.path":                            {desc: "Path of API docs UI", def: "docs"},
+		"docs.specPaths":                       {desc: "Paths of swagger or open API spec files"},
+		"docs.headers":                         {desc: "Headers added into responses"},
+		"docs.style.theme":                     {desc: "Theme of UI, light or dark", def: "light"},
+		"docs.debug":                           {desc: "Enable debug mode", def: "false"},
+		"commonService.enabled":                {desc: "Enable common APIs like /rk/v1/ready", def: "false"},
+		"commonService.pathPrefix":             {desc: "Prefix of path of common APIs", def: "/rk/v1/"},
+		"static.enabled":                       {desc: "Enable static file handler", def: "false"},
+		"static.path":                          {desc: "Path of static file handler", def: "/static"},
+		"static.sourceType":                    {desc: "Type of source, local or pkger"},
+		"static.sourcePath":                    {desc: "Full path of source directory"},
+		"pprof.enabled":                        {desc: "Enable pprof", def: "false"},
+		"pprof.path":                           {desc: "Path of pprof", def: "/pprof"},
+		"prom.enabled":                         {desc: "Enable prometheus", def: "false"},
+		"prom.path":                            {desc: "Path of metrics", def: "metrics"},
+		"prom.pusher.enabled":                  {desc: "Enable pushgateway pusher", def: "false"},
+		"prom.pusher.jobName":                  {desc: "Job name of pusher"},
+		"prom.pusher.remoteAddress":            {desc: "Address of pushgateway"},
+		"prom.pusher.basicAuth":                {desc: "Basic auth of pushgateway, like user:pass"},
+		"prom.pusher.intervalMs":               {desc: "Interval of pushing in milliseconds", def: "1000"},
+		"prom.pusher.certEntry":                {desc: "Reference of cert entry"},
+		"middleware.ignore":                    {desc: "Paths ignored by every middleware"},
+		"middleware.errorModel":                {desc: "Model of error response, google or amazon", def: "google"},
+		"middleware.logging.loggerEncoding":    {desc: "Encoding of logger, console or json", def: "console"},
+		"middleware.logging.loggerOutputPaths": {desc: "Paths of logging output", def: "[stdout]"},
+		"middleware.logging.eventEncoding":     {desc: "Encoding of event, console or json", def: "console"},
+		"middleware.logging.eventOutputPaths":  {desc: "Paths of event output", def: "[stdout]"},
+		"middleware.auth.basic":                {desc: "Basic auth credentials, like user:pass"},
+		"middleware.auth.apiKey":               {desc: "API keys"},
+		"middleware.meta.prefix":               {desc: "Prefix of meta headers", def: "rk"},
+		"middleware.trace.exporter":            {desc: "Exporter of traces, STDOUT would be used if missing"},
+		"middleware.rateLimit.algorithm":       {desc: "Algorithm of rate limit, tokenBucket or leakyBucket", def: "tokenBucket"},
+		"middleware.rateLimit.reqPerSec":       {desc: "Requests allowed per second", def: "1000000"},
+		"middleware.timeout.timeoutMs":         {desc: "Timeout of requests in milliseconds", def: "5000"},
+		"middleware.jwt.signerEntry":           {desc: "Reference of signer entry"},
+		"middleware.jwt.skipVerify":            {desc: "Skip verifying signature of tokens", def: "false"},
+		"middleware.jwt.tokenLookup":           {desc: "Where to look up token, like header:<name>", def: "header:Authorization"},
+		"middleware.jwt.authScheme":            {desc: "Auth scheme of token", def: "Bearer"},
+		"middleware.secure.xssProtection":      {desc: "X-XSS-Protection header", def: "1; mode=block"},
+		"middleware.secure.contentTypeNosniff": {desc: "X-Content-Type-Options header", def: "nosniff"},
+		"middleware.secure.xFrameOptions":      {desc: "X-Frame-Options header", def: "SAMEORIGIN"},
+		"middleware.csrf.tokenLength":          {desc: "Length of token", def: "32"},
+		"middleware.csrf.tokenLookup":          {desc: "Where to look up token", def: "header:X-CSRF-Token"},
+		"middleware.csrf.cookieName":           {desc: "Name of cookie", def: "_csrf"},
+		"middleware.csrf.cookieMaxAge":         {desc: "Max age of cookie in seconds", def: "86400"},
+		"middleware.gzip.level":                {desc: "Level of compression"},
+		"middleware.cors.allowOrigins":         {desc: "Origins allowed, like http://localhost:*, all origins are allowed if missing"},
+		"middleware.cors.allowCredentials":     {desc: "Allow credentials", def: "false"},
+		"middleware.cors.maxAge":               {desc: "Max age of preflight response in seconds", def: "0"},
+	}
+
+	// fields shared by middlewares
+	for _, v := range []string{"logging", "prom", "auth", "meta", "trace", "rateLimit", "timeout", "jwt", "secure", "csrf", "gzip", "cors"} {
+		res["middleware."+v+".enabled"] = &schemaDoc{desc: "Enable middleware", def: "false"}
+		res["middleware."+v+".ignore"] = &schemaDoc{desc: "Paths ignored by middleware"}
+	}
+
+	return res
+}
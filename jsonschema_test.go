@@ -0,0 +1,161 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type utSchemaConfig struct {
+	Name    string         `yaml:"name" desc:"Name of partner" validate:"required"`
+	Partner *partnerConfig `yaml:"partner"`
+	Tags    map[string]int `yaml:"tags" validate:"max=3"`
+	Ignored string         `yaml:"-"`
+}
+
+func TestConfigJSONSchema(t *testing.T) {
+	RegisterConfigSchema("utSchema", utSchemaConfig{})
+	defer delete(configSchemas, "utSchema")
+
+	bytes, err := ConfigJSONSchema()
+	assert.Nil(t, err)
+
+	schema := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(bytes, &schema))
+	assert.Equal(t, jsonSchemaDraft, schema["$schema"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Contains(t, properties, includeKey)
+	assert.Contains(t, properties, "app")
+	assert.Contains(t, properties, "logger")
+
+	// single object or list of objects
+	section := properties["utSchema"].(map[string]interface{})
+	oneOf := section["oneOf"].([]interface{})
+	assert.Len(t, oneOf, 2)
+	item := oneOf[0].(map[string]interface{})
+	assert.Equal(t, item, oneOf[1].(map[string]interface{})["items"])
+
+	assert.Equal(t, []interface{}{"name"}, item["required"])
+	props := item["properties"].(map[string]interface{})
+	assert.NotContains(t, props, "Ignored")
+	assert.NotContains(t, props, "-")
+	assert.Contains(t, props, whenKey)
+	assert.Equal(t, map[string]interface{}{"type": "string", "description": "Name of partner"}, props["name"])
+	assert.Equal(t, float64(3), props["tags"].(map[string]interface{})["maxProperties"])
+
+	// nested struct with defaults and rules
+	partner := props["partner"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, true, partner["enabled"].(map[string]interface{})["default"])
+	assert.Equal(t, float64(1), partner["endpoints"].(map[string]interface{})["minItems"])
+
+	endpoint := partner["endpoints"].(map[string]interface{})["items"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"name"}, endpoint["required"])
+	port := endpoint["properties"].(map[string]interface{})["port"].(map[string]interface{})
+	assert.Equal(t, "integer", port["type"])
+	assert.Equal(t, float64(8080), port["default"])
+	assert.Equal(t, float64(1), port["minimum"])
+	assert.Equal(t, float64(65535), port["maximum"])
+	scheme := endpoint["properties"].(map[string]interface{})["scheme"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"http", "https"}, scheme["enum"])
+
+	// defaults section doesn't require fields
+	defaults := properties[defaultsKey].(map[string]interface{})["properties"].(map[string]interface{})
+	def := defaults["utSchema"].(map[string]interface{})
+	assert.NotContains(t, def, "required")
+	assert.Contains(t, def["properties"], "name")
+	assert.NotContains(t, def["properties"], whenKey)
+	assert.NotContains(t, defaults["logger"].(map[string]interface{})["properties"], whenKey)
+
+	// built-in sections
+	logger := properties["logger"].(map[string]interface{})["oneOf"].([]interface{})[0].(map[string]interface{})
+	zap := logger["properties"].(map[string]interface{})["zap"].(map[string]interface{})
+	level := zap["properties"].(map[string]interface{})["level"].(map[string]interface{})
+	assert.Equal(t, "info", level["default"])
+	assert.NotEmpty(t, level["description"])
+
+	app := properties["app"].(map[string]interface{})["oneOf"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "rk-app", app["properties"].(map[string]interface{})["name"].(map[string]interface{})["default"])
+}
+
+// utWebFrameConfig is config type exported by web frame without desc tags
+type utWebFrameConfig struct {
+	Name       string `yaml:"name"`
+	Port       uint64 `yaml:"port" desc:"Port of ut frame"`
+	Middleware struct {
+		JWT struct {
+			Enabled     bool   `yaml:"enabled"`
+			TokenLookup string `yaml:"tokenLookup"`
+		} `yaml:"jwt"`
+	} `yaml:"middleware"`
+	EnableReflection bool `yaml:"enableReflection"`
+}
+
+func TestConfigJSONSchema_WebFrame(t *testing.T) {
+	RegisterConfigSchema("utGin", utWebFrameConfig{})
+	defer delete(configSchemas, "utGin")
+
+	bytes, err := configJSONSchema([]string{"utGin", "utGrpc", "utOther"}, map[string]bool{"utGin": true, "utGrpc": true})
+	assert.Nil(t, err)
+
+	schema := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(bytes, &schema))
+	properties := schema["properties"].(map[string]interface{})
+
+	// schema is reflected from type declared by web frame, shared fields are documented
+	item := properties["utGin"].(map[string]interface{})["oneOf"].([]interface{})[0].(map[string]interface{})
+	props := item["properties"].(map[string]interface{})
+	assert.Equal(t, "Port of ut frame", props["port"].(map[string]interface{})["description"])
+	assert.Equal(t, "Required, name of entry", props["name"].(map[string]interface{})["description"])
+	assert.Contains(t, props, "enableReflection")
+	assert.NotContains(t, props, "sw")
+
+	middleware := props["middleware"].(map[string]interface{})["properties"].(map[string]interface{})
+	jwt := middleware["jwt"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, false, jwt["enabled"].(map[string]interface{})["default"])
+	assert.Equal(t, "header:Authorization", jwt["tokenLookup"].(map[string]interface{})["default"])
+
+	// web frame without declared schema and unknown plugin accept any object
+	for _, key := range []string{"utGrpc", "utOther"} {
+		other := properties[key].(map[string]interface{})["oneOf"].([]interface{})[0]
+		assert.Equal(t, map[string]interface{}{"type": "object"}, other)
+	}
+}
+
+func TestBoot_PrintSchemaIfRequested(t *testing.T) {
+	boot := &Boot{}
+	buf := &bytes.Buffer{}
+
+	// flag missing
+	printed, err := boot.printSchemaIfRequested(buf)
+	assert.False(t, printed)
+	assert.Nil(t, err)
+	assert.Empty(t, buf.String())
+
+	boot.args = []string{"--rk.print-schema"}
+	printed, err = boot.printSchemaIfRequested(buf)
+	assert.True(t, printed)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), jsonSchemaDraft)
+
+	// NewBootE would exit before reading boot config
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = os.Exit
+	}()
+	NewBoot(WithBootConfigRaw([]byte("{}")), func(boot *Boot) {
+		boot.args = []string{"--rk.print-schema"}
+	})
+	assert.Equal(t, 0, exitCode)
+}
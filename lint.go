@@ -0,0 +1,403 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lintFlag is command line flag of linting boot config and exit, exit code would be 1 if any error found
+const lintFlag = "rk.lint"
+
+// LintSeverity is severity of lint issue
+type LintSeverity string
+
+const (
+	// LintSeverityInfo is issue which is fine in most cases
+	LintSeverityInfo LintSeverity = "info"
+	// LintSeverityWarn is issue which should be reviewed
+	LintSeverityWarn LintSeverity = "warn"
+	// LintSeverityError is issue which should be fixed, --rk.lint would exit with code 1
+	LintSeverityError LintSeverity = "error"
+)
+
+// lintSeverityRanks is used while sorting issues, higher first
+var lintSeverityRanks = map[LintSeverity]int{
+	LintSeverityInfo:  0,
+	LintSeverityWarn:  1,
+	LintSeverityError: 2,
+}
+
+var (
+	// prodProfiles is profiles treated as production
+	prodProfiles = []string{"prod", "production"}
+
+	// lintLock guards lintRules, rules could be registered while linting
+	lintLock = sync.RWMutex{}
+	// lintRules is registered lint rules keyed by name
+	lintRules = map[string]*lintRule{
+		"tls-disabled":              {severity: LintSeverityInfo, rule: lintTLSDisabled},
+		"debug-in-prod":             {severity: LintSeverityError, rule: lintDebugInProd},
+		"cors-wildcard-credentials": {severity: LintSeverityError, rule: lintCORSWildcard},
+		"missing-logger":            {severity: LintSeverityInfo, rule: lintMissingLogger},
+		"jwt-skip-verify":           {severity: LintSeverityError, rule: lintJWTSkipVerify},
+	}
+)
+
+// LintRule check effective boot config and returns issues, Rule and Severity of issues would be filled by Boot.Lint
+type LintRule func(config *LintConfig) []*LintIssue
+
+// lintRule is a registered lint rule
+type lintRule struct {
+	severity LintSeverity
+	rule     LintRule
+}
+
+// RegisterLintRule register lint rule with name and severity, rule registered with existing name would replace it,
+// which could be used to change severity of built-in rules. Bellow rules are registered by default:
+//
+//	tls-disabled:              info,  web entry serves on a port without certEntry, which is fine behind TLS terminating proxy
+//	debug-in-prod:             error, pprof or sw enabled in prod profile
+//	cors-wildcard-credentials: error, cors allows origin *, or any origin if allowOrigins is missing, with allowCredentials
+//	missing-logger:            info,  no logger entry declared
+//	jwt-skip-verify:           error, jwt enabled with skipVerify
+//
+// Pass nil rule to disable rule with name.
+func RegisterLintRule(name string, severity LintSeverity, rule LintRule) {
+	if len(name) < 1 {
+		return
+	}
+
+	lintLock.Lock()
+	defer lintLock.Unlock()
+
+	if rule == nil {
+		delete(lintRules, name)
+		return
+	}
+
+	lintRules[name] = &lintRule{severity: severity, rule: rule}
+}
+
+// LintIssue is an issue found by lint rule
+type LintIssue struct {
+	// Rule is name of lint rule
+	Rule string
+	// Severity of issue, severity of rule would be used if missing
+	Severity LintSeverity
+	// Path of value in boot config, like gin.greeter.middleware.jwt.skipVerify
+	Path string
+	// Source of value, filled by Boot.Lint
+	Source ConfigSource
+	// Message describes issue
+	Message string
+}
+
+// String returns issue like
+// error jwt-skip-verify gin.greeter.middleware.jwt.skipVerify (boot.yaml:12): jwt tokens would not be verified
+func (i *LintIssue) String() string {
+	location := i.Path
+	if len(i.Source.Kind) > 0 {
+		location = fmt.Sprintf("%s (%s)", i.Path, i.Source.String())
+	}
+
+	return fmt.Sprintf("%s %s %s: %s", i.Severity, i.Rule, location, i.Message)
+}
+
+// LintConfig is effective boot config passed to lint rules
+type LintConfig struct {
+	// Profiles is active profiles, see WithProfile for details
+	Profiles []string
+	root     *yaml.Node
+}
+
+// Keys returns top level keys in boot config
+func (c *LintConfig) Keys() []string {
+	res := make([]string, 0)
+	if c.root == nil {
+		return res
+	}
+
+	for i := 0; i+1 < len(c.root.Content); i += 2 {
+		res = append(res, c.root.Content[i].Value)
+	}
+
+	return res
+}
+
+// Items returns paths of items in top level section, like gin.greeter, or gin[1] if item has no name
+func (c *LintConfig) Items(key string) []string {
+	res := make([]string, 0)
+	if c.root == nil {
+		return res
+	}
+
+	_, section := mappingValue(c.root, key)
+	if section == nil {
+		return res
+	}
+
+	switch section.Kind {
+	case yaml.MappingNode:
+		res = append(res, key)
+	case yaml.SequenceNode:
+		for i, item := range section.Content {
+			if name := itemName(item); len(name) > 0 && !strings.ContainsAny(name, ".[]") {
+				res = append(res, joinConfigPath(key, name))
+			} else {
+				res = append(res, fmt.Sprintf("%s[%d]", key, i))
+			}
+		}
+	}
+
+	return res
+}
+
+// Get returns value of path in boot config, false would be returned if path is missing
+func (c *LintConfig) Get(path string) (interface{}, bool) {
+	if c.root == nil {
+		return nil, false
+	}
+
+	node, err := lookupConfigNode(c.root, path)
+	if err != nil || node == nil {
+		return nil, false
+	}
+
+	var res interface{}
+	if err := node.Decode(&res); err != nil {
+		return nil, false
+	}
+
+	return res, true
+}
+
+// GetBool returns true if value of path is true
+func (c *LintConfig) GetBool(path string) bool {
+	v, _ := c.Get(path)
+	res, _ := v.(bool)
+	return res
+}
+
+// GetString returns value of path as string, empty string would be returned if missing
+func (c *LintConfig) GetString(path string) string {
+	v, ok := c.Get(path)
+	if !ok || v == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// GetStrings returns value of path as list of strings, a single value would be treated as list of one element
+func (c *LintConfig) GetStrings(path string) []string {
+	v, ok := c.Get(path)
+	if !ok || v == nil {
+		return []string{}
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%v", v)}
+	}
+
+	res := make([]string, 0, len(list))
+	for _, e := range list {
+		res = append(res, fmt.Sprintf("%v", e))
+	}
+
+	return res
+}
+
+// Lint run registered lint rules over effective boot config, issues are sorted by severity and path.
+//
+// Boot config could also be linted by running application with --rk.lint flag, issues would be printed
+// and application would exit without bootstrapping entries, exit code would be 1 if any error found.
+//
+// Use RegisterLintRule to add custom rules or change severity of built-in rules.
+func (boot *Boot) Lint() []*LintIssue {
+	root, sources := boot.configSnapshot()
+	config := &LintConfig{
+		Profiles: boot.activeProfiles(),
+		root:     root,
+	}
+
+	// rules would be run without lock, since rules may register rules as well
+	lintLock.RLock()
+	rules := make(map[string]*lintRule, len(lintRules))
+	names := make([]string, 0, len(lintRules))
+	for name, r := range lintRules {
+		rules[name] = r
+		names = append(names, name)
+	}
+	lintLock.RUnlock()
+	sort.Strings(names)
+
+	res := make([]*LintIssue, 0)
+	for _, name := range names {
+		r := rules[name]
+		for _, issue := range r.rule(config) {
+			if issue == nil {
+				continue
+			}
+			if len(issue.Rule) < 1 {
+				issue.Rule = name
+			}
+			if len(issue.Severity) < 1 {
+				issue.Severity = r.severity
+			}
+			if source, ok := configSourceOf(root, sources, issue.Path); ok {
+				issue.Source = source
+			}
+			res = append(res, issue)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Severity != res[j].Severity {
+			return lintSeverityRanks[res[i].Severity] > lintSeverityRanks[res[j].Severity]
+		}
+		return res[i].Path < res[j].Path
+	})
+
+	return res
+}
+
+// lintIfRequested print lint issues into w if --rk.lint flag was provided, exit code would be returned
+func (boot *Boot) lintIfRequested(w io.Writer) (bool, int, error) {
+	ok := false
+	for _, arg := range boot.args {
+		ok = ok || strings.TrimLeft(arg, "-") == lintFlag
+	}
+
+	if !ok {
+		return false, 0, nil
+	}
+
+	code := 0
+	for _, issue := range boot.Lint() {
+		if issue.Severity == LintSeverityError {
+			code = 1
+		}
+		if _, err := fmt.Fprintln(w, issue.String()); err != nil {
+			return true, code, err
+		}
+	}
+
+	return true, code, nil
+}
+
+// webItems returns paths of enabled items serving on a port, like items of gin and grpc
+func (c *LintConfig) webItems() []string {
+	res := make([]string, 0)
+	for _, key := range c.Keys() {
+		for _, item := range c.Items(key) {
+			if c.GetBool(item+".enabled") && len(c.GetString(item+".port")) > 0 {
+				res = append(res, item)
+			}
+		}
+	}
+
+	return res
+}
+
+// lintTLSDisabled report web entries serving on a port without certEntry
+func lintTLSDisabled(config *LintConfig) []*LintIssue {
+	res := make([]*LintIssue, 0)
+	for _, item := range config.webItems() {
+		if len(config.GetString(item+".certEntry")) < 1 {
+			res = append(res, &LintIssue{
+				Path:    item + ".port",
+				Message: "TLS is disabled since certEntry is missing, traffic would be served in plain text",
+			})
+		}
+	}
+
+	return res
+}
+
+// lintDebugInProd report pprof and sw enabled while prod profile is active
+func lintDebugInProd(config *LintConfig) []*LintIssue {
+	res := make([]*LintIssue, 0)
+	if !containsAny(prodProfiles, config.Profiles) {
+		return res
+	}
+
+	for _, item := range config.webItems() {
+		for _, key := range []string{"pprof", "sw"} {
+			if path := item + "." + key + ".enabled"; config.GetBool(path) {
+				res = append(res, &LintIssue{
+					Path:    path,
+					Message: fmt.Sprintf("%s is enabled in profile of %s", key, strings.Join(config.Profiles, ",")),
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+// lintCORSWildcard report cors allows any origin with credentials, cors middleware allows any origin
+// if allowOrigins is missing or empty
+func lintCORSWildcard(config *LintConfig) []*LintIssue {
+	res := make([]*LintIssue, 0)
+	for _, item := range config.webItems() {
+		cors := item + ".middleware.cors"
+		if !config.GetBool(cors+".enabled") || !config.GetBool(cors+".allowCredentials") {
+			continue
+		}
+
+		origins := config.GetStrings(cors + ".allowOrigins")
+		if len(origins) > 0 && !containsAny(origins, []string{"*"}) {
+			continue
+		}
+
+		path := cors + ".allowOrigins"
+		if _, ok := config.Get(path); !ok {
+			path = cors + ".allowCredentials"
+		}
+		res = append(res, &LintIssue{
+			Path:    path,
+			Message: "any origin is allowed with allowCredentials, credentials would be exposed to every site",
+		})
+	}
+
+	return res
+}
+
+// lintMissingLogger report boot config without logger entry
+func lintMissingLogger(config *LintConfig) []*LintIssue {
+	if len(config.Items("logger")) > 0 {
+		return []*LintIssue{}
+	}
+
+	return []*LintIssue{{
+		Path:    "logger",
+		Message: "no logger entry is declared, entries would log into STDOUT",
+	}}
+}
+
+// lintJWTSkipVerify report jwt middleware which doesn't verify tokens
+func lintJWTSkipVerify(config *LintConfig) []*LintIssue {
+	res := make([]*LintIssue, 0)
+	for _, item := range config.webItems() {
+		jwt := item + ".middleware.jwt"
+		if config.GetBool(jwt+".enabled") && config.GetBool(jwt+".skipVerify") {
+			res = append(res, &LintIssue{
+				Path:    jwt + ".skipVerify",
+				Message: "signature of jwt tokens would not be verified",
+			})
+		}
+	}
+
+	return res
+}
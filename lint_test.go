@@ -0,0 +1,180 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lintConfig = `
+gin:
+  - name: greeter
+    enabled: true
+    port: 8080
+    sw:
+      enabled: true
+    middleware:
+      cors:
+        enabled: true
+        allowOrigins: ["*"]
+        allowCredentials: true
+      jwt:
+        enabled: true
+        skipVerify: true
+  - name: admin
+    enabled: false
+    port: 8081
+    pprof:
+      enabled: true
+`
+
+func newLintBoot(t *testing.T, profiles ...string) *Boot {
+	boot, err := NewBootE(WithBootConfigRaw([]byte(lintConfig)), WithProfile(profiles...))
+	assert.Nil(t, err)
+	return boot
+}
+
+func TestBoot_Lint(t *testing.T) {
+	// without prod profile
+	issues := newLintBoot(t, "dev").Lint()
+
+	res := make([]string, 0)
+	for _, v := range issues {
+		res = append(res, v.String())
+	}
+	assert.Equal(t, []string{
+		"error cors-wildcard-credentials gin.greeter.middleware.cors.allowOrigins (<raw>:11): any origin is allowed with allowCredentials, credentials would be exposed to every site",
+		"error jwt-skip-verify gin.greeter.middleware.jwt.skipVerify (<raw>:15): signature of jwt tokens would not be verified",
+		"info tls-disabled gin.greeter.port (<raw>:5): TLS is disabled since certEntry is missing, traffic would be served in plain text",
+		"info missing-logger logger: no logger entry is declared, entries would log into STDOUT",
+	}, res)
+
+	// sw of enabled entry is reported in prod profile, pprof of disabled entry is not
+	issues = newLintBoot(t, "prod").Lint()
+	assert.Len(t, issues, 5)
+	assert.Equal(t, "debug-in-prod", issues[2].Rule)
+	assert.Equal(t, "gin.greeter.sw.enabled", issues[2].Path)
+	assert.Equal(t, ConfigSource{Kind: ConfigSourceFile, File: "<raw>", Line: 7}, issues[2].Source)
+}
+
+func TestLintCORSWildcard(t *testing.T) {
+	config := `
+gin:
+  - name: missing
+    enabled: true
+    port: 8080
+    middleware:
+      cors:
+        enabled: true
+        allowCredentials: true
+  - name: empty
+    enabled: true
+    port: 8081
+    middleware:
+      cors:
+        enabled: true
+        allowOrigins: []
+        allowCredentials: true
+  - name: listed
+    enabled: true
+    port: 8082
+    middleware:
+      cors:
+        enabled: true
+        allowOrigins: ["https://example.com"]
+        allowCredentials: true
+`
+	boot, err := NewBootE(WithBootConfigRaw([]byte(config)))
+	assert.Nil(t, err)
+
+	// missing or empty allowOrigins allows any origin
+	paths := make([]string, 0)
+	for _, v := range boot.Lint() {
+		if v.Rule == "cors-wildcard-credentials" {
+			paths = append(paths, v.Path)
+		}
+	}
+	assert.Equal(t, []string{
+		"gin.empty.middleware.cors.allowOrigins",
+		"gin.missing.middleware.cors.allowCredentials",
+	}, paths)
+}
+
+func TestRegisterLintRule(t *testing.T) {
+	defer RegisterLintRule("ut-rule", LintSeverityWarn, nil)
+	defer RegisterLintRule("missing-logger", LintSeverityInfo, lintMissingLogger)
+
+	RegisterLintRule("ut-rule", LintSeverityWarn, func(config *LintConfig) []*LintIssue {
+		res := make([]*LintIssue, 0)
+		for _, item := range config.Items("gin") {
+			if config.GetString(item+".port") == "8081" {
+				res = append(res, &LintIssue{Path: item + ".port", Message: "port 8081 is reserved"})
+			}
+		}
+		return res
+	})
+	// disable built-in rule
+	RegisterLintRule("missing-logger", LintSeverityInfo, nil)
+
+	issues := newLintBoot(t).Lint()
+	assert.Len(t, issues, 4)
+	assert.Equal(t, "ut-rule", issues[2].Rule)
+	assert.Equal(t, LintSeverityWarn, issues[2].Severity)
+	assert.Equal(t, "gin.admin.port", issues[2].Path)
+
+	// rules could be registered while linting
+	boot := newLintBoot(t)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterLintRule("ut-rule", LintSeverityInfo, lintMissingLogger)
+		}()
+		go func() {
+			defer wg.Done()
+			boot.Lint()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBoot_LintIfRequested(t *testing.T) {
+	boot := newLintBoot(t)
+	buf := &bytes.Buffer{}
+
+	// flag missing
+	linted, code, err := boot.lintIfRequested(buf)
+	assert.False(t, linted)
+	assert.Equal(t, 0, code)
+	assert.Nil(t, err)
+	assert.Empty(t, buf.String())
+
+	boot.args = []string{"--rk.lint"}
+	linted, code, err = boot.lintIfRequested(buf)
+	assert.True(t, linted)
+	assert.Equal(t, 1, code)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "error jwt-skip-verify gin.greeter.middleware.jwt.skipVerify")
+
+	// NewBootE would exit before registering entries, code is 0 without errors
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = os.Exit
+	}()
+	NewBoot(WithBootConfigRaw([]byte("logger:\n  - name: my-logger\n")), func(boot *Boot) {
+		boot.args = []string{"--rk.lint"}
+	})
+	assert.Equal(t, 0, exitCode)
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// overrideFlag is command line flag of overriding value in boot config
+	overrideFlag = "rk.set"
+	// overrideEnvPrefix is prefix of environment variables of overriding value in boot config
+	overrideEnvPrefix = "RK_"
+	// overrideEnvSeparator separates segments of path in environment variables
+	overrideEnvSeparator = "__"
+	// legacyOverrideFlag is command line flag of overriding values which is handled by rkentry.UnmarshalBootYAML
+	legacyOverrideFlag = "rkset"
+)
+
+// configOverride is one overridden value in boot config
+type configOverride struct {
+	// source of override, like flag --rk.set or env RK_GIN__GREETER__PORT
+	source ConfigSource
+	// path of value, like gin.greeter.port
+	path string
+	// segments of path, nil if path is parsed from flag
+	segments []string
+	value    string
+}
+
+// parseFlagOverrides parse --rk.set flags in args.
+//
+// Both --rk.set gin.greeter.port=9090 and --rk.set=gin.greeter.port=9090 are supported.
+func parseFlagOverrides(args []string) ([]*configOverride, error) {
+	res := make([]*configOverride, 0)
+
+	for i := 0; i < len(args); i++ {
+		v, ok := flagValue(args, &i, overrideFlag)
+		if !ok {
+			continue
+		}
+
+		tokens := strings.SplitN(v, "=", 2)
+		if len(tokens) != 2 || len(tokens[0]) < 1 {
+			return nil, fmt.Errorf("invalid --%s %q, expect path=value", overrideFlag, v)
+		}
+
+		res = append(res, &configOverride{
+			source: ConfigSource{Kind: ConfigSourceFlag, Name: "--" + overrideFlag},
+			path:   tokens[0],
+			value:  tokens[1],
+		})
+	}
+
+	return res, nil
+}
+
+// flagValue returns value of flag at args[*i], *i would be moved forward if value is the next arg
+func flagValue(args []string, i *int, name string) (string, bool) {
+	arg := args[*i]
+	if !strings.HasPrefix(arg, "-") {
+		return "", false
+	}
+
+	arg = strings.TrimLeft(arg, "-")
+	if arg == name {
+		if *i+1 < len(args) {
+			*i++
+			return args[*i], true
+		}
+		return "", true
+	}
+
+	if strings.HasPrefix(arg, name+"=") {
+		return strings.TrimPrefix(arg, name+"="), true
+	}
+
+	return "", false
+}
+
+// parseEnvOverrides parse environment variables like RK_GIN__GREETER__PORT=9090.
+//
+// Segments of path are separated by double underscores in order to distinguish from RK_GIN_0_PORT
+// which is parsed by parseLegacyEnvOverrides.
+func parseEnvOverrides(environ []string) []*configOverride {
+	res := make([]*configOverride, 0)
+
+	for _, v := range environ {
+		tokens := strings.SplitN(v, "=", 2)
+		if len(tokens) != 2 || !strings.HasPrefix(tokens[0], overrideEnvPrefix) {
+			continue
+		}
+
+		segments := strings.Split(strings.TrimPrefix(tokens[0], overrideEnvPrefix), overrideEnvSeparator)
+		if len(segments) < 2 {
+			continue
+		}
+
+		valid := true
+		for _, seg := range segments {
+			valid = valid && len(seg) > 0
+		}
+		if !valid {
+			continue
+		}
+
+		res = append(res, &configOverride{
+			source:   ConfigSource{Kind: ConfigSourceEnv, Name: tokens[0]},
+			path:     strings.ToLower(strings.Join(segments, ".")),
+			segments: segments,
+			value:    tokens[1],
+		})
+	}
+
+	// make sequence of overrides stable
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].source.Name < res[j].source.Name
+	})
+
+	return res
+}
+
+// parseLegacyEnvOverrides parse environment variables like RK_GIN_0_PORT=9090, which are applied again by
+// rkentry.UnmarshalBootYAML in reg funcs. Variables whose first segment is not a top level key in root are
+// ignored, like RK_PROFILE.
+func parseLegacyEnvOverrides(environ []string, root *yaml.Node) []*configOverride {
+	res := make([]*configOverride, 0)
+
+	for _, v := range environ {
+		tokens := strings.SplitN(v, "=", 2)
+		if len(tokens) != 2 || !strings.HasPrefix(tokens[0], overrideEnvPrefix) ||
+			strings.Contains(tokens[0], overrideEnvSeparator) {
+			continue
+		}
+
+		// RK_GIN_0_PORT => gin[0].port
+		path := ""
+		for i, seg := range strings.Split(strings.ToLower(strings.TrimPrefix(tokens[0], overrideEnvPrefix)), "_") {
+			if _, err := strconv.Atoi(seg); err == nil && i > 0 {
+				path = fmt.Sprintf("%s[%s]", path, seg)
+			} else {
+				path = joinConfigPath(path, seg)
+			}
+		}
+
+		segments, err := parseConfigPath(path)
+		if err != nil || len(segments) < 2 {
+			continue
+		}
+		if _, section := mappingValue(root, segments[0].key); section == nil {
+			continue
+		}
+
+		res = append(res, &configOverride{
+			source: ConfigSource{Kind: ConfigSourceEnv, Name: tokens[0]},
+			path:   path,
+			value:  tokens[1],
+		})
+	}
+
+	// make sequence of overrides stable
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].source.Name < res[j].source.Name
+	})
+
+	return res
+}
+
+// parseLegacyFlagOverrides parse --rkset flags like --rkset "gin[0].port=9090,gin[0].enabled=false", which
+// are applied again by rkentry.UnmarshalBootYAML in reg funcs
+func parseLegacyFlagOverrides(args []string) []*configOverride {
+	res := make([]*configOverride, 0)
+
+	for i := 0; i < len(args); i++ {
+		v, ok := flagValue(args, &i, legacyOverrideFlag)
+		if !ok {
+			continue
+		}
+
+		for _, pair := range strings.Split(v, ",") {
+			tokens := strings.SplitN(pair, "=", 2)
+			if len(tokens) != 2 || len(tokens[0]) < 1 {
+				continue
+			}
+
+			res = append(res, &configOverride{
+				source: ConfigSource{Kind: ConfigSourceFlag, Name: "--" + legacyOverrideFlag},
+				path:   tokens[0],
+				value:  tokens[1],
+			})
+		}
+	}
+
+	return res
+}
+
+// applyOverride set value of override into root, missing keys would be created for flags, while environment
+// variables could only override existing paths since they may be set for other purposes
+func applyOverride(root *yaml.Node, override *configOverride, sources configProvenance) error {
+	value := overrideValueNode(override.value)
+	sources.annotate(value, override.source)
+
+	if override.segments != nil {
+		return setEnvPath(root, override.segments, value)
+	}
+
+	return setConfigValue(root, override.path, value)
+}
+
+// setConfigValue set value of path like gin.greeter.port or gin[0].port into root, missing keys would be created
+func setConfigValue(root *yaml.Node, path string, value *yaml.Node) error {
+	segments, err := parseConfigPath(path)
+	if err != nil {
+		return err
+	}
+
+	node := root
+	for i, seg := range segments {
+		last := i == len(segments)-1
+
+		switch {
+		case node.Kind == yaml.MappingNode && !seg.isIndex():
+			k, v := mappingValue(node, seg.key)
+			if v == nil {
+				k, v = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg.key}, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				node.Content = append(node.Content, k, v)
+			}
+			if last {
+				replaceMappingValue(node, k, value)
+				return nil
+			}
+			node = v
+		case node.Kind == yaml.SequenceNode:
+			child := childNode(node, seg)
+			if child == nil {
+				return fmt.Errorf("failed to set %s, list item %s not found", path, seg.String())
+			}
+			if last {
+				replaceSequenceItem(node, child, value)
+				return nil
+			}
+			node = child
+		default:
+			return fmt.Errorf("failed to set %s, %s is not a mapping or list", path, seg.String())
+		}
+	}
+
+	return nil
+}
+
+// setEnvPath set value with segments parsed from environment variable, path must exist in node.
+//
+// Keys are matched case-insensitive, list items are matched by name field with dashes and dots
+// as underscores, or by index.
+func setEnvPath(node *yaml.Node, segments []string, value *yaml.Node) error {
+	seg, last := segments[0], len(segments) == 1
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		k, v := mappingValue(node, seg)
+		if v == nil {
+			return fmt.Errorf("key %s not found", seg)
+		}
+		if last {
+			replaceMappingValue(node, k, value)
+			return nil
+		}
+		return setEnvPath(v, segments[1:], value)
+	case yaml.SequenceNode:
+		var child *yaml.Node
+		if index, err := strconv.Atoi(seg); err == nil && index >= 0 && index < len(node.Content) {
+			child = node.Content[index]
+		}
+		for _, item := range node.Content {
+			if child == nil && strings.EqualFold(envName(itemName(item)), seg) {
+				child = item
+			}
+		}
+		if child == nil {
+			return fmt.Errorf("list item %s not found", seg)
+		}
+		if last {
+			replaceSequenceItem(node, child, value)
+			return nil
+		}
+		return setEnvPath(child, segments[1:], value)
+	}
+
+	return fmt.Errorf("%s is not a mapping or list", seg)
+}
+
+// envName convert name of list item into form of environment variable
+func envName(name string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+// overrideValueNode parse value as YAML, so that numbers, booleans and lists keep their types
+func overrideValueNode(value string) *yaml.Node {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(value), doc); err == nil && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// replaceMappingValue replace value of key in mapping node
+func replaceMappingValue(node, key, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i] == key {
+			node.Content[i+1] = value
+		}
+	}
+}
+
+// replaceSequenceItem replace item in sequence node
+func replaceSequenceItem(node, item, value *yaml.Node) {
+	for i := range node.Content {
+		if node.Content[i] == item {
+			node.Content[i] = value
+		}
+	}
+}
+
+// applyOverrides apply overrides from environment variables and then command line flags, legacy overrides
+// like RK_GIN_0_PORT and --rkset are applied at last.
+//
+// Environment variables whose path doesn't exist in boot config, like items removed by when conditions, are
+// skipped and returned as messages.
+func (boot *Boot) applyOverrides() ([]*configOverride, []string, error) {
+	flags, err := parseFlagOverrides(boot.args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overrides, skipped := make([]*configOverride, 0), make([]string, 0)
+	for _, v := range parseEnvOverrides(boot.environ) {
+		if err := applyOverride(boot.config, v, boot.sources); err != nil {
+			skipped = append(skipped, fmt.Sprintf("Skip override from %s of path %s, %v", v.source.String(), v.path, err))
+			continue
+		}
+		overrides = append(overrides, v)
+	}
+
+	for _, v := range flags {
+		if err := applyOverride(boot.config, v, boot.sources); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply override from %s, %v", v.source.String(), err)
+		}
+		overrides = append(overrides, v)
+	}
+
+	// Legacy overrides win since rkentry.UnmarshalBootYAML applies them again over config reg funcs received,
+	// invalid ones are ignored as rkentry does
+	legacy := append(parseLegacyEnvOverrides(boot.environ, boot.config), parseLegacyFlagOverrides(boot.args)...)
+	for _, v := range legacy {
+		if err := applyOverride(boot.config, v, boot.sources); err == nil {
+			overrides = append(overrides, v)
+		}
+	}
+
+	return overrides, skipped, nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const overrideConfig = `
+gin:
+  - name: greeter
+    port: 8080
+  - name: my-admin
+    port: 8081
+`
+
+func TestParseFlagOverrides(t *testing.T) {
+	res, err := parseFlagOverrides([]string{
+		"--rk.set", "gin.greeter.port=9090",
+		"-rk.set=gin[1].port=9091",
+		"--other", "value",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "gin.greeter.port", res[0].path)
+	assert.Equal(t, "9090", res[0].value)
+	assert.Equal(t, "gin[1].port", res[1].path)
+	assert.Equal(t, "9091", res[1].value)
+
+	// invalid flag
+	_, err = parseFlagOverrides([]string{"--rk.set", "gin.greeter.port"})
+	assert.NotNil(t, err)
+}
+
+func TestParseEnvOverrides(t *testing.T) {
+	res := parseEnvOverrides([]string{
+		"RK_GIN__MY_ADMIN__PORT=9091",
+		"RK_GIN_0_PORT=9092",
+		"RK_GIN____PORT=9093",
+		"HOME=/root",
+	})
+	assert.Len(t, res, 1)
+	assert.Equal(t, "env RK_GIN__MY_ADMIN__PORT", res[0].source.String())
+	assert.Equal(t, "gin.my_admin.port", res[0].path)
+	assert.Equal(t, []string{"GIN", "MY_ADMIN", "PORT"}, res[0].segments)
+}
+
+func TestParseLegacyOverrides(t *testing.T) {
+	root, _ := parseConfig([]byte(overrideConfig), ConfigFormatYAML)
+
+	res := parseLegacyEnvOverrides([]string{
+		"RK_GIN_1_COMMONSERVICE_ENABLED=true",
+		"RK_GIN_0_PORT=9092",
+		"RK_GIN__GREETER__PORT=9093",
+		"RK_PROFILE=prod",
+		"RK_ECHO_0_PORT=9094",
+	}, root)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "gin[0].port", res[0].path)
+	assert.Equal(t, "env RK_GIN_0_PORT", res[0].source.String())
+	assert.Equal(t, "gin[1].commonservice.enabled", res[1].path)
+
+	res = parseLegacyFlagOverrides([]string{"--rkset", "gin[0].port=9090,gin[1].port=9091", "--rkset=invalid"})
+	assert.Len(t, res, 2)
+	assert.Equal(t, "gin[1].port", res[1].path)
+	assert.Equal(t, "9091", res[1].value)
+	assert.Equal(t, "flag --rkset", res[1].source.String())
+}
+
+func TestApplyOverride(t *testing.T) {
+	root, _ := parseConfig([]byte(overrideConfig), ConfigFormatYAML)
+
+	// flag with named item
+	assert.Nil(t, applyOverride(root, &configOverride{path: "gin.greeter.port", value: "9090"}, configProvenance{}))
+	// env with named item
+	assert.Nil(t, applyOverride(root, &configOverride{
+		path: "gin.my_admin.port", segments: []string{"GIN", "MY_ADMIN", "PORT"}, value: "9091",
+	}, configProvenance{}))
+	// missing keys would be created
+	assert.Nil(t, applyOverride(root, &configOverride{path: "gin[0].middleware.ignore", value: "[/a, /b]"}, configProvenance{}))
+	// env could only override existing keys
+	assert.Nil(t, applyOverride(root, &configOverride{
+		path: "gin.0.middleware.ignore", segments: []string{"GIN", "0", "MIDDLEWARE", "IGNORE"}, value: "[/c]",
+	}, configProvenance{}))
+	assert.NotNil(t, applyOverride(root, &configOverride{
+		path: "gin.0.commonservice.enabled", segments: []string{"GIN", "0", "COMMONSERVICE", "ENABLED"}, value: "true",
+	}, configProvenance{}))
+
+	actual := map[string]interface{}{}
+	assert.Nil(t, root.Decode(&actual))
+	expected := map[string]interface{}{}
+	assert.Nil(t, yaml.Unmarshal([]byte(`
+gin:
+  - name: greeter
+    port: 9090
+    middleware:
+      ignore: [/c]
+  - name: my-admin
+    port: 9091
+`), &expected))
+	assert.Equal(t, expected, actual)
+
+	// missing list item
+	assert.NotNil(t, applyOverride(root, &configOverride{path: "gin.missing.port", value: "1"}, configProvenance{}))
+	assert.NotNil(t, applyOverride(root, &configOverride{
+		path: "gin.missing.port", segments: []string{"GIN", "MISSING", "PORT"}, value: "1",
+	}, configProvenance{}))
+	// not a mapping or list
+	assert.NotNil(t, applyOverride(root, &configOverride{path: "gin.greeter.port.value", value: "1"}, configProvenance{}))
+}
+
+func TestNewBoot_WithOverrides(t *testing.T) {
+	boot := NewBoot(
+		WithBootConfigRaw([]byte(overrideConfig)),
+		func(boot *Boot) {
+			boot.args = []string{"--rk.set", "gin.greeter.port=9090"}
+			boot.environ = []string{"RK_GIN__GREETER__PORT=9091", "RK_GIN__MY_ADMIN__PORT=9092"}
+		})
+
+	// flags override environment variables
+	node, _ := lookupConfigNode(boot.config, "gin.greeter.port")
+	assert.Equal(t, "9090", node.Value)
+	node, _ = lookupConfigNode(boot.config, "gin.my-admin.port")
+	assert.Equal(t, "9092", node.Value)
+
+	// legacy overrides win as rkentry.UnmarshalBootYAML applies them at last
+	boot = NewBoot(
+		WithBootConfigRaw([]byte(overrideConfig)),
+		func(boot *Boot) {
+			boot.args = []string{"--rk.set", "gin.greeter.port=9090", "--rkset", "gin[1].port=9095"}
+			boot.environ = []string{"RK_GIN_0_PORT=9094", "RK_GIN_9_PORT=9096"}
+		})
+	node, _ = lookupConfigNode(boot.config, "gin.greeter.port")
+	assert.Equal(t, "9094", node.Value)
+	source, _ := boot.ConfigSource("gin.greeter.port")
+	assert.Equal(t, "env RK_GIN_0_PORT", source.String())
+	node, _ = lookupConfigNode(boot.config, "gin.my-admin.port")
+	assert.Equal(t, "9095", node.Value)
+	source, _ = boot.ConfigSource("gin.my-admin.port")
+	assert.Equal(t, "flag --rkset", source.String())
+
+	// env overrides of paths missing in boot config are skipped, even in strict mode
+	boot, err := NewBootE(
+		WithBootConfigRaw([]byte(overrideConfig+"  - name: ut-skipped\n    when:\n      profile: [ut-missing]\n")),
+		WithStrictConfig(),
+		func(boot *Boot) {
+			boot.environ = []string{"RK_UTMISSING__KEY=1", "RK_GIN__UT_SKIPPED__PORT=9097", "RK_GIN__GREETER__PORT=9098"}
+		})
+	assert.Nil(t, err)
+	node, _ = lookupConfigNode(boot.config, "gin.greeter.port")
+	assert.Equal(t, "9098", node.Value)
+	_, section := mappingValue(boot.config, "utmissing")
+	assert.Nil(t, section)
+
+	// invalid override
+	_, err = NewBootE(
+		WithBootConfigRaw([]byte(overrideConfig)),
+		func(boot *Boot) {
+			boot.args = []string{"--rk.set", "gin.missing.port=9090"}
+		})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"go.uber.org/zap"
+)
+
+// entryInterfaceType is reflect type of rkentry.Entry
+var entryInterfaceType = reflect.TypeOf((*rkentry.Entry)(nil)).Elem()
+
+// WithPortPreflight test-bind every port declared by web entries while creating Boot, so that ports held
+// by other processes would fail fast before any entry is bootstrapped.
+//
+// Duplicated ports among web entries are always checked.
+func WithPortPreflight() BootOption {
+	return func(boot *Boot) {
+		boot.portPreflight = true
+	}
+}
+
+// entryPort is a port declared by web entry or its sub entries like prom and pprof
+type entryPort struct {
+	entry rkentry.Entry
+	// path of field, like Port or PromEntry.Port
+	path string
+	// value of field
+	value reflect.Value
+}
+
+// port returns value of port field
+func (p *entryPort) port() uint64 {
+	if p.value.CanUint() {
+		return p.value.Uint()
+	}
+
+	if v := p.value.Int(); v > 0 {
+		return uint64(v)
+	}
+
+	return 0
+}
+
+// String returns owner of port, like GinEntry/greeter.Port
+func (p *entryPort) String() string {
+	return entryKey(p.entry) + "." + p.path
+}
+
+// webEntryPorts returns ports declared by web entries, sorted by entry and field
+func (boot *Boot) webEntryPorts() []*entryPort {
+	res := make([]*entryPort, 0)
+	for _, byName := range boot.webEntries {
+		for _, e := range byName {
+			collectPorts(e, reflect.ValueOf(e), "", map[uintptr]bool{}, &res)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].String() < res[j].String()
+	})
+
+	return res
+}
+
+// collectPorts collect exported integer fields named Port or ending with Port, like GwPort, in v and
+// sub entries of it
+func collectPorts(entry rkentry.Entry, v reflect.Value, path string, visited map[uintptr]bool, res *[]*entryPort) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Ptr {
+			if visited[v.Pointer()] {
+				return
+			}
+			visited[v.Pointer()] = true
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := field.Name
+		if len(path) > 0 {
+			fieldPath = path + "." + field.Name
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if strings.HasSuffix(field.Name, "Port") {
+				*res = append(*res, &entryPort{entry: entry, path: fieldPath, value: v.Field(i)})
+			}
+		case reflect.Ptr, reflect.Interface:
+			// sub entries like prom and pprof may serve on their own ports
+			if field.Type.Implements(entryInterfaceType) && !field.Anonymous {
+				collectPorts(entry, v.Field(i), fieldPath, visited, res)
+			}
+		}
+	}
+}
+
+// checkPorts returns error if ports declared by web entries are duplicated, or in use if preflight enabled
+func (boot *Boot) checkPorts() error {
+	owners := map[uint64][]string{}
+	ports := make([]uint64, 0)
+	for _, p := range boot.webEntryPorts() {
+		port := p.port()
+		// port 0 means random port
+		if port == 0 {
+			continue
+		}
+
+		if _, ok := owners[port]; !ok {
+			ports = append(ports, port)
+		}
+		owners[port] = append(owners[port], p.String())
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+
+	res := make([]string, 0)
+	for _, port := range ports {
+		if len(owners[port]) > 1 {
+			res = append(res, fmt.Sprintf("port %d is declared by %s", port, strings.Join(owners[port], " and ")))
+			continue
+		}
+
+		if !boot.portPreflight {
+			continue
+		}
+
+		lis, err := net.Listen("tcp", ":"+strconv.FormatUint(port, 10))
+		if err != nil {
+			res = append(res, fmt.Sprintf("port %d of %s is unavailable, %v", port, owners[port][0], err))
+			continue
+		}
+		lis.Close()
+	}
+
+	if len(res) > 0 {
+		return fmt.Errorf("port conflicts in web entries, %s", strings.Join(res, "; "))
+	}
+
+	return nil
+}
+
+// BoundAddrEntry is implemented by web entries which could report address actually listened after bootstrapped.
+//
+// Web entries implementing it would listen on port 0 as it is, other web entries declared with port 0 would be
+// assigned a free port while creating Boot.
+type BoundAddrEntry interface {
+	rkentry.Entry
+
+	// BoundAddr returns address listened by entry, nil if entry is not listening
+	BoundAddr() net.Addr
+}
+
+// WithAddrFile write bound addresses of web entries into file as JSON after bootstrapped, like
+// {"GinEntry": {"greeter": "[::]:8080"}}, which is useful for tests and sidecars with port 0.
+func WithAddrFile(filePath string) BootOption {
+	return func(boot *Boot) {
+		boot.addrFile = filePath
+	}
+}
+
+// Addr returns bound address of web entry after bootstrapped, false would be returned if entry doesn't exist
+// or address is unknown.
+//
+// Address is reported by entry if it implements BoundAddrEntry, which is unknown until entry listens,
+// otherwise localhost with Port field of entry, including port allocated for port 0, would be returned.
+func (boot *Boot) Addr(entryType, entryName string) (string, bool) {
+	addr, ok := boot.webEntryAddrs()[entryType][entryName]
+	return addr, ok
+}
+
+// allocatePorts assign free ports to web entries declared with port 0 which don't implement BoundAddrEntry,
+// since address listened by them could not be known otherwise. Ports of sub entries are not touched since
+// 0 usually means disabled or sharing port of parent.
+func (boot *Boot) allocatePorts() error {
+	for _, p := range boot.webEntryPorts() {
+		if _, ok := p.entry.(BoundAddrEntry); ok || p.path != "Port" || p.port() != 0 || !p.value.CanSet() {
+			continue
+		}
+
+		lis, err := net.Listen("tcp", ":0")
+		if err != nil {
+			return fmt.Errorf("failed to allocate port of %s, %v", p.String(), err)
+		}
+		port := lis.Addr().(*net.TCPAddr).Port
+		lis.Close()
+
+		if p.value.CanUint() {
+			p.value.SetUint(uint64(port))
+		} else {
+			p.value.SetInt(int64(port))
+		}
+	}
+
+	return nil
+}
+
+// webEntryAddrs returns bound addresses of web entries keyed by type and name
+func (boot *Boot) webEntryAddrs() map[string]map[string]string {
+	res := map[string]map[string]string{}
+	add := func(e rkentry.Entry, addr string) {
+		if res[e.GetType()] == nil {
+			res[e.GetType()] = map[string]string{}
+		}
+		res[e.GetType()][e.GetName()] = addr
+	}
+
+	for _, byName := range boot.webEntries {
+		for _, e := range byName {
+			if v, ok := e.(BoundAddrEntry); ok {
+				if addr := v.BoundAddr(); addr != nil {
+					add(e, addr.String())
+				}
+			}
+		}
+	}
+
+	// fallback to Port field of entries not reporting address, declared port of entries which are not
+	// listening yet is not bound
+	for _, p := range boot.webEntryPorts() {
+		if _, ok := p.entry.(BoundAddrEntry); ok || p.path != "Port" || p.port() == 0 {
+			continue
+		}
+		add(p.entry, net.JoinHostPort("localhost", strconv.FormatUint(p.port(), 10)))
+	}
+
+	return res
+}
+
+// reportAddrs log bound addresses of web entries and write them into addr file if provided
+func (boot *Boot) reportAddrs() error {
+	addrs := boot.webEntryAddrs()
+
+	types := make([]string, 0)
+	for entryType := range addrs {
+		types = append(types, entryType)
+	}
+	sort.Strings(types)
+
+	for _, entryType := range types {
+		names := make([]string, 0)
+		for name := range addrs[entryType] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			rkentry.GlobalAppCtx.GetLoggerEntryDefault().Info("Bound address of web entry",
+				zap.String("entryType", entryType),
+				zap.String("entryName", name),
+				zap.String("addr", addrs[entryType][name]))
+		}
+	}
+
+	if len(boot.addrFile) < 1 {
+		return nil
+	}
+
+	bytes, err := json.MarshalIndent(addrs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write into temporary file first, so that readers would never see partially written file
+	tmp := boot.addrFile + ".tmp"
+	if err := os.WriteFile(tmp, bytes, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, boot.addrFile)
+}
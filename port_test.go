@@ -0,0 +1,172 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type utWebEntry struct {
+	name      string
+	Port      uint64
+	PromEntry *utPromEntry
+}
+
+func (entry *utWebEntry) Bootstrap(context.Context) {}
+
+func (entry *utWebEntry) Interrupt(context.Context) {}
+
+func (entry *utWebEntry) GetName() string {
+	return entry.name
+}
+
+func (entry *utWebEntry) GetType() string {
+	return "UtWebEntry"
+}
+
+func (entry *utWebEntry) GetDescription() string {
+	return ""
+}
+
+func (entry *utWebEntry) String() string {
+	return entry.name
+}
+
+type utPromEntry struct {
+	utWebEntry
+	Port int
+}
+
+func TestBoot_CheckPorts(t *testing.T) {
+	boot := NewBoot(WithBootConfigRaw([]byte("{}")))
+
+	boot.AddEntry(&utWebEntry{name: "greeter", Port: 8080, PromEntry: &utPromEntry{Port: 9090}}, WebTier)
+	boot.AddEntry(&utWebEntry{name: "admin", Port: 8081}, WebTier)
+	boot.AddEntry(&utWebEntry{name: "random"}, WebTier)
+	assert.Nil(t, boot.checkPorts())
+
+	ports := boot.webEntryPorts()
+	assert.Len(t, ports, 4)
+	assert.Equal(t, "UtWebEntry/greeter.PromEntry.Port", ports[2].String())
+	assert.Equal(t, uint64(9090), ports[2].port())
+
+	// duplicated with sub entry
+	boot.AddEntry(&utWebEntry{name: "metrics", Port: 9090}, WebTier)
+	err := boot.checkPorts()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "port 9090 is declared by UtWebEntry/greeter.PromEntry.Port and UtWebEntry/metrics.Port")
+}
+
+func TestBoot_WithPortPreflight(t *testing.T) {
+	lis, err := net.Listen("tcp", ":0")
+	assert.Nil(t, err)
+	defer lis.Close()
+	port, _ := strconv.ParseUint(strconv.Itoa(lis.Addr().(*net.TCPAddr).Port), 10, 64)
+
+	boot := NewBoot(WithBootConfigRaw([]byte("{}")), WithPortPreflight())
+	boot.AddEntry(&utWebEntry{name: "greeter", Port: port}, WebTier)
+
+	err = boot.checkPorts()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "UtWebEntry/greeter.Port is unavailable")
+
+	// without preflight
+	boot.portPreflight = false
+	assert.Nil(t, boot.checkPorts())
+}
+
+// utListenEntry listens on Port while bootstrapping and reports bound address
+type utListenEntry struct {
+	utWebEntry
+	lis net.Listener
+}
+
+func (entry *utListenEntry) Bootstrap(context.Context) {
+	entry.lis, _ = net.Listen("tcp", "127.0.0.1:"+strconv.FormatUint(entry.Port, 10))
+}
+
+func (entry *utListenEntry) Interrupt(context.Context) {
+	if entry.lis != nil {
+		entry.lis.Close()
+	}
+}
+
+func (entry *utListenEntry) BoundAddr() net.Addr {
+	if entry.lis == nil {
+		return nil
+	}
+	return entry.lis.Addr()
+}
+
+func TestBoot_Addr(t *testing.T) {
+	addrFile := filepath.Join(t.TempDir(), "addr.json")
+	boot := NewBoot(WithBootConfigRaw([]byte("{}")), WithAddrFile(addrFile))
+
+	greeter := &utListenEntry{utWebEntry: utWebEntry{name: "greeter", PromEntry: &utPromEntry{}}}
+	boot.AddEntry(greeter, WebTier)
+	boot.AddEntry(&utWebEntry{name: "admin", Port: 8081}, WebTier)
+	random := &utWebEntry{name: "random", PromEntry: &utPromEntry{}}
+	boot.AddEntry(random, WebTier)
+
+	// address is unknown before bootstrapped
+	_, ok := boot.Addr("UtWebEntry", "greeter")
+	assert.False(t, ok)
+
+	boot.Bootstrap(context.Background())
+	defer greeter.Interrupt(context.Background())
+
+	// port 0 is passed to BoundAddrEntry as it is
+	assert.Zero(t, greeter.Port)
+	assert.Zero(t, greeter.PromEntry.Port)
+
+	// port 0 of other entries would be allocated, ports of sub entries are not touched
+	assert.NotZero(t, random.Port)
+	assert.Zero(t, random.PromEntry.Port)
+	allocated := net.JoinHostPort("localhost", strconv.FormatUint(random.Port, 10))
+
+	bound := greeter.lis.Addr().String()
+	addr, ok := boot.Addr("UtWebEntry", "greeter")
+	assert.True(t, ok)
+	assert.Equal(t, bound, addr)
+
+	addr, ok = boot.Addr("UtWebEntry", "admin")
+	assert.True(t, ok)
+	assert.Equal(t, "localhost:8081", addr)
+
+	addr, ok = boot.Addr("UtWebEntry", "random")
+	assert.True(t, ok)
+	assert.Equal(t, allocated, addr)
+
+	_, ok = boot.Addr("UtWebEntry", "missing")
+	assert.False(t, ok)
+
+	// addr file
+	bytes, err := os.ReadFile(addrFile)
+	assert.Nil(t, err)
+	addrs := map[string]map[string]string{}
+	assert.Nil(t, json.Unmarshal(bytes, &addrs))
+	assert.Equal(t, map[string]map[string]string{
+		"UtWebEntry": {
+			"greeter": bound,
+			"admin":   "localhost:8081",
+			"random":  allocated,
+		},
+	}, addrs)
+
+	// declared port of BoundAddrEntry is not reported before it listens
+	boot = NewBoot(WithBootConfigRaw([]byte("{}")))
+	boot.AddEntry(&utListenEntry{utWebEntry: utWebEntry{name: "idle", Port: 8082}}, WebTier)
+	_, ok = boot.Addr("UtWebEntry", "idle")
+	assert.False(t, ok)
+}
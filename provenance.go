@@ -0,0 +1,154 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSourceKind is kind of source where value in boot config came from
+type ConfigSourceKind string
+
+const (
+	// ConfigSourceFile means value was declared in boot config file
+	ConfigSourceFile ConfigSourceKind = "file"
+	// ConfigSourceEnv means value was overridden by environment variable
+	ConfigSourceEnv ConfigSourceKind = "env"
+	// ConfigSourceFlag means value was overridden by command line flag
+	ConfigSourceFlag ConfigSourceKind = "flag"
+	// ConfigSourceDefault means value was filled by defaults
+	ConfigSourceDefault ConfigSourceKind = "default"
+	// ConfigSourceSecret means value was resolved from secret reference
+	ConfigSourceSecret ConfigSourceKind = "secret"
+)
+
+// ConfigSource is where value in boot config came from
+type ConfigSource struct {
+	Kind ConfigSourceKind `yaml:"kind" json:"kind"`
+	// File is name of file, available if Kind is ConfigSourceFile
+	File string `yaml:"file" json:"file"`
+	// Line is line number in file, zero if format of file doesn't keep line numbers, like JSON
+	Line int `yaml:"line" json:"line"`
+	// Name is name of environment variable, command line flag or secret reference
+	Name string `yaml:"name" json:"name"`
+}
+
+// String returns source like boot.yaml:12, env RK_GIN__GREETER__PORT or flag --rk.set
+func (s ConfigSource) String() string {
+	file := ConfigSource{Kind: ConfigSourceFile, File: s.File, Line: s.Line}
+
+	switch s.Kind {
+	case ConfigSourceFile:
+		if s.Line > 0 {
+			return fmt.Sprintf("%s:%d", s.File, s.Line)
+		}
+		return s.File
+	case ConfigSourceEnv, ConfigSourceFlag:
+		return fmt.Sprintf("%s %s", s.Kind, s.Name)
+	case ConfigSourceDefault:
+		if len(s.File) > 0 {
+			return fmt.Sprintf("%s from %s", s.Kind, file)
+		}
+	case ConfigSourceSecret:
+		if len(s.File) > 0 {
+			return fmt.Sprintf("%s from %s", s.Name, file)
+		}
+		return s.Name
+	}
+
+	return string(s.Kind)
+}
+
+// ConfigSource returns where value of path in effective boot config came from.
+//
+// path is like gin.greeter.port or gin[0].port, false would be returned if path is missing.
+func (boot *Boot) ConfigSource(path string) (ConfigSource, bool) {
+	config, sources := boot.configSnapshot()
+	return configSourceOf(config, sources, path)
+}
+
+// configSourceOf returns source of value of path in config
+func configSourceOf(config *yaml.Node, sources configProvenance, path string) (ConfigSource, bool) {
+	if config == nil {
+		return ConfigSource{}, false
+	}
+
+	node, err := lookupConfigNode(config, path)
+	if err != nil || node == nil {
+		return ConfigSource{}, false
+	}
+
+	res, ok := sources[node]
+	if !ok {
+		return ConfigSource{Kind: ConfigSourceDefault}, true
+	}
+
+	return *res, true
+}
+
+// configProvenance records source of nodes in boot config
+type configProvenance map[*yaml.Node]*ConfigSource
+
+// annotate record source of node and its children, line number would be filled from node if source is file
+func (p configProvenance) annotate(node *yaml.Node, source ConfigSource) {
+	if node == nil {
+		return
+	}
+
+	res := source
+	if res.Kind == ConfigSourceFile || (res.Kind == ConfigSourceDefault && len(res.File) > 0) {
+		res.Line = node.Line
+	}
+	p[node] = &res
+
+	for _, v := range node.Content {
+		p.annotate(v, source)
+	}
+}
+
+// copyNodeWithSource returns deep copy of node with source of scalar values as line comments,
+// values resolved from secret references would be redacted
+func (p configProvenance) copyNodeWithSource(node *yaml.Node) *yaml.Node {
+	res := copyNode(node)
+	p.commentSource(node, res)
+	return res
+}
+
+// commentSource set source of scalar values in src as line comments of dst, which is a copy of src
+func (p configProvenance) commentSource(src, dst *yaml.Node) {
+	// aliases are expanded in dst, secrets in anchored nodes must be redacted as well
+	for src.Kind == yaml.AliasNode && src.Alias != nil {
+		src = src.Alias
+	}
+
+	dst.HeadComment, dst.LineComment, dst.FootComment = "", "", ""
+	if src.Kind != yaml.ScalarNode {
+		// comments in flow style are hard to read
+		dst.Style &^= yaml.FlowStyle
+	}
+
+	switch src.Kind {
+	case yaml.ScalarNode:
+		if v, ok := p[src]; ok {
+			dst.LineComment = v.String()
+			if v.Kind == ConfigSourceSecret {
+				dst.Tag, dst.Style, dst.Value = "!!str", 0, redactedValue
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key := dst.Content[i]
+			key.HeadComment, key.LineComment, key.FootComment = "", "", ""
+			p.commentSource(src.Content[i+1], dst.Content[i+1])
+		}
+	default:
+		for i := range src.Content {
+			p.commentSource(src.Content[i], dst.Content[i])
+		}
+	}
+}
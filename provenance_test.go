@@ -0,0 +1,71 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoot_ConfigSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"boot.yaml": &fstest.MapFile{Data: []byte(`
+$include: fragments/gin.json
+gin:
+  - name: greeter
+    port: 8080
+    enabled: true
+`)},
+		"fragments/gin.json": &fstest.MapFile{Data: []byte(`{"gin": [{"name": "admin", "port": 8081}]}`)},
+		"boot-override.yaml": &fstest.MapFile{Data: []byte(`
+gin:
+  - name: greeter
+    port: 8080
+  - name: admin
+    port: 8081
+`)},
+	}
+
+	boot := NewBoot(WithBootConfigFS(fsys, "boot.yaml"))
+
+	res, ok := boot.ConfigSource("gin.greeter.port")
+	assert.True(t, ok)
+	assert.Equal(t, ConfigSource{Kind: ConfigSourceFile, File: "boot.yaml", Line: 5}, res)
+	assert.Equal(t, "boot.yaml:5", res.String())
+
+	// list of gin in boot.yaml overrides the one in fragment
+	_, ok = boot.ConfigSource("gin.admin.port")
+	assert.False(t, ok)
+
+	boot = NewBoot(WithBootConfigFS(fsys, "boot-override.yaml"), func(boot *Boot) {
+		boot.args = []string{"--rk.set", "gin.greeter.port=9090"}
+		boot.environ = []string{"RK_GIN__ADMIN__PORT=9091"}
+	})
+
+	res, _ = boot.ConfigSource("gin.greeter.port")
+	assert.Equal(t, "flag --rk.set", res.String())
+	res, _ = boot.ConfigSource("gin.admin.port")
+	assert.Equal(t, "env RK_GIN__ADMIN__PORT", res.String())
+	res, _ = boot.ConfigSource("gin[1].name")
+	assert.Equal(t, "boot-override.yaml:5", res.String())
+
+	// source would be included in effective config
+	dump, _ := boot.EffectiveConfig(ConfigFormatYAML)
+	assert.Contains(t, string(dump), "port: 9090 # flag --rk.set")
+
+	// missing path
+	_, ok = boot.ConfigSource("gin.missing.port")
+	assert.False(t, ok)
+}
+
+func TestConfigSource_String(t *testing.T) {
+	assert.Equal(t, "boot.json", ConfigSource{Kind: ConfigSourceFile, File: "boot.json"}.String())
+	assert.Equal(t, "default", ConfigSource{Kind: ConfigSourceDefault}.String())
+	assert.Equal(t, "default from boot.yaml:3",
+		ConfigSource{Kind: ConfigSourceDefault, File: "boot.yaml", Line: 3}.String())
+}
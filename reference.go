@@ -0,0 +1,117 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"gopkg.in/yaml.v3"
+)
+
+// entryReferences is fields in boot config which reference entries, keyed by lower case of field
+var entryReferences = map[string]*entryReference{
+	"loggerentry": {field: "loggerEntry", entryType: rkentry.LoggerEntryType},
+	"evententry":  {field: "eventEntry", entryType: rkentry.EventEntryType},
+	"certentry":   {field: "certEntry", entryType: rkentry.CertEntryType},
+	"signerentry": {field: "signerEntry", entryType: rkentry.SignerJwtEntryType},
+}
+
+// entryReference is a field which references entry of type
+type entryReference struct {
+	field     string
+	entryType string
+}
+
+// RegisterEntryReference declare field in boot config whose value is name of entry with entryType.
+//
+// NewBoot would verify that every referenced entry exists after all entries are registered, fields
+// are matched case-insensitive at any level of boot config, sections with enabled: false are skipped. Bellow fields are declared by default:
+//
+//	loggerEntry: LoggerEntry
+//	eventEntry:  EventEntry
+//	certEntry:   CertEntry
+//	signerEntry: SignerJwtEntry
+func RegisterEntryReference(field, entryType string) {
+	if len(field) < 1 || len(entryType) < 1 {
+		return
+	}
+
+	entryReferences[strings.ToLower(field)] = &entryReference{field: field, entryType: entryType}
+}
+
+// checkEntryReferences returns error if any entry referenced in node is missing
+func (boot *Boot) checkEntryReferences(node *yaml.Node) error {
+	res := make([]string, 0)
+	boot.collectMissingReferences(node, "", &res)
+
+	if len(res) > 0 {
+		return fmt.Errorf("invalid entry references in boot config, %s", strings.Join(res, "; "))
+	}
+
+	return nil
+}
+
+// collectMissingReferences walk through node and collect messages of missing entries
+func (boot *Boot) collectMissingReferences(node *yaml.Node, path string, res *[]string) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if name := itemName(item); len(name) > 0 {
+				itemPath = joinConfigPath(path, name)
+			}
+			boot.collectMissingReferences(item, itemPath, res)
+		}
+	case yaml.MappingNode:
+		// entries and middlewares disabled would never resolve references
+		if _, enabled := mappingValue(node, "enabled"); enabled != nil && enabled.Kind == yaml.ScalarNode {
+			if v, err := strconv.ParseBool(enabled.Value); err == nil && !v {
+				return
+			}
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinConfigPath(path, key.Value)
+
+			ref, ok := entryReferences[strings.ToLower(key.Value)]
+			if !ok || value.Kind != yaml.ScalarNode {
+				boot.collectMissingReferences(value, keyPath, res)
+				continue
+			}
+
+			if len(value.Value) < 1 || value.Tag == "!!null" {
+				continue
+			}
+
+			if rkentry.GlobalAppCtx.GetEntry(ref.entryType, value.Value) == nil {
+				*res = append(*res, boot.missingReferenceMessage(ref, keyPath, value))
+			}
+		}
+	}
+}
+
+// missingReferenceMessage returns message like
+// gin.greeter.certEntry (boot.yaml:12) references CertEntry "my-cret" which doesn't exist, available: [my-cert]
+func (boot *Boot) missingReferenceMessage(ref *entryReference, path string, value *yaml.Node) string {
+	available := make([]string, 0)
+	for name := range rkentry.GlobalAppCtx.ListEntriesByType(ref.entryType) {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+
+	location := fmt.Sprintf("line %d", value.Line)
+	if v, ok := boot.sources[value]; ok {
+		location = v.String()
+	}
+
+	return fmt.Sprintf("%s (%s) references %s %q which doesn't exist, available: [%s]",
+		path, location, ref.entryType, value.Value, strings.Join(available, ", "))
+}
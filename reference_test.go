@@ -0,0 +1,94 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBoot_CheckEntryReferences(t *testing.T) {
+	defer func() {
+		rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry(rkentry.LoggerEntryType, "ut-ref-logger"))
+		rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry(rkentry.EventEntryType, "ut-ref-event"))
+	}()
+
+	config := `
+logger:
+  - name: ut-ref-logger
+event:
+  - name: ut-ref-event
+gin:
+  - name: greeter
+    loggerEntry: ut-ref-logger
+    eventEntry: ut-ref-event
+    certEntry: ""
+`
+	_, err := NewBootE(WithBootConfigRaw([]byte(config)))
+	assert.Nil(t, err)
+
+	// with typo
+	config = `
+logger:
+  - name: ut-ref-logger
+gin:
+  - name: greeter
+    middleware:
+      logging:
+        loggerEntry: ut-ref-loger
+`
+	_, err = NewBootE(WithBootConfigRaw([]byte(config)))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(),
+		`gin.greeter.middleware.logging.loggerEntry (<raw>:8) references LoggerEntry "ut-ref-loger" which doesn't exist`)
+	assert.Contains(t, err.Error(), "ut-ref-logger")
+
+	// with wrong type
+	_, err = NewBootE(WithBootConfigRaw([]byte(`
+logger:
+  - name: ut-ref-logger
+gin:
+  - name: greeter
+    eventEntry: ut-ref-logger
+`)))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `references EventEntry "ut-ref-logger"`)
+
+	// disabled entries and middlewares are skipped
+	_, err = NewBootE(WithBootConfigRaw([]byte(`
+gin:
+  - name: greeter
+    enabled: false
+    certEntry: ut-ref-missing
+  - name: ut-ref-gin
+    middleware:
+      logging:
+        enabled: "false"
+        loggerEntry: ut-ref-missing
+`)))
+	assert.Nil(t, err)
+}
+
+func TestRegisterEntryReference(t *testing.T) {
+	defer delete(entryReferences, "utmyentry")
+	RegisterEntryReference("utMyEntry", "MyEntry")
+
+	// declared by user
+	_, err := NewBootE(WithBootConfigRaw([]byte(`
+gin:
+  - name: greeter
+    utMyEntry: missing
+`)))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `gin.greeter.utMyEntry (<raw>:4) references MyEntry "missing"`)
+
+	// ignore invalid declaration
+	RegisterEntryReference("", "MyEntry")
+	_, ok := entryReferences[""]
+	assert.False(t, ok)
+}
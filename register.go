@@ -0,0 +1,181 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"gopkg.in/yaml.v3"
+)
+
+var userEntryRegFuncs = make([]*userEntryRegFunc, 0)
+
+// userEntryRegFunc is registration function of user entry which reports errors
+type userEntryRegFunc struct {
+	yamlKey string
+	regF    func(raw []byte) ([]rkentry.Entry, error)
+}
+
+// validator would be called by RegisterUserEntry after config was unmarshalled
+type validator interface {
+	Validate() error
+}
+
+// RegisterUserEntry register user defined entry with typed config.
+//
+// Section of yamlKey in boot config would be unmarshalled into C, either a single object or a list of objects,
+// items without enabled: true would be skipped. If C or *C implements Validate() error, it would be called
+// before f. Entries returned by f would be registered into rkentry.GlobalAppCtx and bootstrapped as user entries.
+//
+// Any error would be returned from NewBootE.
+//
+// Example:
+//
+//	---
+//	myEntry:
+//	  - name: my-entry
+//	    enabled: true
+//
+//	type MyConfig struct {
+//	  Name    string `yaml:"name"`
+//	  Enabled bool   `yaml:"enabled"`
+//	}
+//
+//	func init() {
+//	  rkboot.RegisterUserEntry[MyConfig]("myEntry", func(c MyConfig) (rkentry.Entry, error) {
+//	    return &MyEntry{name: c.Name}, nil
+//	  })
+//	}
+func RegisterUserEntry[C any](yamlKey string, f func(C) (rkentry.Entry, error)) {
+	if len(yamlKey) < 1 || f == nil {
+		return
+	}
+
+	var config C
+	RegisterConfigSchema(yamlKey, config)
+
+	userEntryRegFuncs = append(userEntryRegFuncs, &userEntryRegFunc{
+		yamlKey: yamlKey,
+		regF: func(raw []byte) ([]rkentry.Entry, error) {
+			return unmarshalUserEntries(raw, yamlKey, f)
+		},
+	})
+}
+
+// unmarshalUserEntries unmarshal section of yamlKey into C and construct entries
+func unmarshalUserEntries[C any](raw []byte, yamlKey string, f func(C) (rkentry.Entry, error)) ([]rkentry.Entry, error) {
+	section, err := lookupTopLevelNode(raw, yamlKey)
+	if err != nil || section == nil {
+		return nil, err
+	}
+
+	items := []*yaml.Node{section}
+	if section.Kind == yaml.SequenceNode {
+		items = section.Content
+	}
+
+	res := make([]rkentry.Entry, 0)
+	names := map[string]bool{}
+	errs := make([]string, 0)
+
+	for i, item := range items {
+		path := yamlKey
+		if section.Kind == yaml.SequenceNode {
+			path = fmt.Sprintf("%s[%d]", yamlKey, i)
+		}
+
+		if !isEnabled(item) {
+			continue
+		}
+
+		var config C
+		if err := item.Decode(&config); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		if err := validate(&config); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		entry, err := f(config)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+
+		if names[entry.GetName()] {
+			errs = append(errs, fmt.Sprintf("%s: duplicate entry name %q", path, entry.GetName()))
+			continue
+		}
+		names[entry.GetName()] = true
+
+		res = append(res, entry)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to register entries of %s, %s", yamlKey, strings.Join(errs, "; "))
+	}
+
+	return res, nil
+}
+
+// validate call Validate() if config implements validator
+func validate(config interface{}) error {
+	if v, ok := reflect.ValueOf(config).Elem().Interface().(validator); ok {
+		return v.Validate()
+	}
+
+	if v, ok := config.(validator); ok {
+		return v.Validate()
+	}
+
+	return nil
+}
+
+// lookupTopLevelNode returns node of top level key, key is case-insensitive as rkentry.UnmarshalBootYAML does
+func lookupTopLevelNode(raw []byte, key string) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(raw, doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) < 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if strings.EqualFold(root.Content[i].Value, key) {
+			return root.Content[i+1], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isEnabled returns true if enabled: true was declared in mapping node
+func isEnabled(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "enabled" {
+			var res bool
+			return node.Content[i+1].Decode(&res) == nil && res
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+type typedEntryConfig struct {
+	Name        string `yaml:"name"`
+	Enabled     bool   `yaml:"enabled"`
+	Description string `yaml:"description"`
+}
+
+func (c *typedEntryConfig) Validate() error {
+	if len(c.Name) < 1 {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func newTypedEntry(c typedEntryConfig) (rkentry.Entry, error) {
+	return &MyEntry{
+		EntryName:        c.Name,
+		EntryType:        "typedEntry",
+		EntryDescription: c.Description,
+	}, nil
+}
+
+func TestUnmarshalUserEntries(t *testing.T) {
+	// single object
+	raw := []byte(`
+typedEntry:
+  name: ut-single
+  enabled: true
+`)
+	entries, err := unmarshalUserEntries(raw, "typedEntry", newTypedEntry)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "ut-single", entries[0].GetName())
+
+	// list of objects, disabled item would be skipped
+	raw = []byte(`
+typedEntry:
+  - name: ut-1
+    enabled: true
+  - name: ut-2
+    enabled: false
+  - name: ut-3
+    enabled: true
+`)
+	entries, err = unmarshalUserEntries(raw, "typedEntry", newTypedEntry)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	// missing section
+	entries, err = unmarshalUserEntries([]byte(`other: {}`), "typedEntry", newTypedEntry)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	// validation failure and duplicate names
+	raw = []byte(`
+typedEntry:
+  - enabled: true
+  - name: ut-dup
+    enabled: true
+  - name: ut-dup
+    enabled: true
+`)
+	entries, err = unmarshalUserEntries(raw, "typedEntry", newTypedEntry)
+	assert.Nil(t, entries)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "typedEntry[0]: name is required")
+	assert.Contains(t, err.Error(), `typedEntry[2]: duplicate entry name "ut-dup"`)
+
+	// constructor failure
+	_, err = unmarshalUserEntries([]byte("typedEntry:\n  name: ut\n  enabled: true"), "typedEntry",
+		func(typedEntryConfig) (rkentry.Entry, error) {
+			return nil, errors.New("expected error")
+		})
+	assert.NotNil(t, err)
+}
+
+func TestRegisterUserEntry(t *testing.T) {
+	RegisterUserEntry("typedEntry", newTypedEntry)
+	defer func() {
+		userEntryRegFuncs = userEntryRegFuncs[:len(userEntryRegFuncs)-1]
+	}()
+
+	boot, err := NewBootE(WithBootConfigRaw([]byte("typedEntry:\n  name: ut-typed\n  enabled: true")))
+	assert.Nil(t, err)
+
+	entry := rkentry.GlobalAppCtx.GetEntry("typedEntry", "ut-typed")
+	assert.NotNil(t, entry)
+	assert.Equal(t, entry, boot.userEntries["typedEntry"]["ut-typed"])
+	rkentry.GlobalAppCtx.RemoveEntry(entry)
+
+	// error would be returned from NewBootE
+	boot, err = NewBootE(WithBootConfigRaw([]byte("typedEntry:\n  enabled: true")))
+	assert.Nil(t, boot)
+	assert.NotNil(t, err)
+}
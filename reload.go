@@ -0,0 +1,492 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// watchDebounce is duration of waiting for more file events before reloading
+var watchDebounce = 100 * time.Millisecond
+
+// Reloadable is implemented by entries which could apply changed boot config without restart.
+type Reloadable interface {
+	// Reload apply changed boot config, newRaw is whole boot config normalized as YAML like reg funcs received.
+	//
+	// Reload would be called with previous boot config if reloading of any entry failed.
+	Reload(ctx context.Context, newRaw []byte) error
+}
+
+// WithConfigWatch watch boot config files and SIGHUP, and reload boot config while they changed.
+// Boot config provided by WithBootConfigURL would be polled, see WithURLPollInterval for details.
+//
+// Watching starts after Bootstrap() and stops while shutting down, SIGHUP would trigger reloading
+// instead of shutting down. See Boot.Reload for details.
+func WithConfigWatch() BootOption {
+	return func(boot *Boot) {
+		boot.watchConfig = true
+	}
+}
+
+// Reload re-read boot config and call Reload() of entries whose sections changed.
+//
+// Entries which are not Reloadable, and sections added or removed, would be logged and need restart to apply
+// changes. If any entry failed to reload, entries reloaded would be rolled back to previous boot config and
+// error would be returned.
+func (boot *Boot) Reload(ctx context.Context) error {
+	boot.reloadLock.Lock()
+	defer boot.reloadLock.Unlock()
+
+	logger := rkentry.GlobalAppCtx.GetLoggerEntryDefault()
+
+	// load into staging boot, so that boot config being read by others is never partially replaced
+	next := boot.stagingBoot()
+	if _, err := next.loadConfig(ctx); err != nil {
+		return fmt.Errorf("failed to reload boot config, %v", err)
+	}
+
+	if err := next.checkEntryReferences(next.config); err != nil {
+		return fmt.Errorf("failed to reload boot config, %v", err)
+	}
+
+	oldConfig, oldSources := boot.configSnapshot()
+	oldFiles := boot.configFiles
+
+	oldRaw, err := yaml.Marshal(oldConfig)
+	if err != nil {
+		return err
+	}
+
+	newRaw, err := yaml.Marshal(next.config)
+	if err != nil {
+		return err
+	}
+
+	boot.swapConfig(next.config, next.sources, next.configFiles)
+
+	for _, path := range boot.changedSections(oldConfig, next.config) {
+		logger.Warn("Boot config section added or removed, restart is required", zap.String("path", path))
+	}
+
+	reloaded := make([]Reloadable, 0)
+	for _, e := range boot.changedEntries(oldConfig, next.config) {
+		r, ok := e.(Reloadable)
+		if !ok {
+			logger.Warn("Boot config of entry changed, restart is required",
+				zap.String("entryType", e.GetType()),
+				zap.String("entryName", e.GetName()))
+			continue
+		}
+
+		reloaded = append(reloaded, r)
+		if err := r.Reload(ctx, newRaw); err != nil {
+			// roll back entries including failed one, since it may be partially reloaded
+			for _, v := range reloaded {
+				if rollbackErr := v.Reload(ctx, oldRaw); rollbackErr != nil {
+					logger.Error("Failed to roll back entry", zap.Error(rollbackErr))
+				}
+			}
+			boot.swapConfig(oldConfig, oldSources, oldFiles)
+
+			return fmt.Errorf("failed to reload entry %s of %s, rolled back, %v", e.GetName(), e.GetType(), err)
+		}
+
+		logger.Info("Reloaded entry",
+			zap.String("entryType", e.GetType()),
+			zap.String("entryName", e.GetName()))
+	}
+
+	// documents are committed only after reloaded, so that a failed reload would be retried by next poll
+	boot.commitURLDocs()
+
+	return nil
+}
+
+// stagingBoot returns Boot with options of boot which are used while loading boot config
+func (boot *Boot) stagingBoot() *Boot {
+	return &Boot{
+		bootConfigPath:      boot.bootConfigPath,
+		configFS:            boot.configFS,
+		bootConfigRaw:       boot.bootConfigRaw,
+		configFormat:        boot.configFormat,
+		strictConfig:        boot.strictConfig,
+		args:                boot.args,
+		environ:             boot.environ,
+		secretResolvers:     boot.secretResolvers,
+		decryptionKeyFile:   boot.decryptionKeyFile,
+		decryptionKeyEnv:    boot.decryptionKeyEnv,
+		configPublicKey:     boot.configPublicKey,
+		configPublicKeyFile: boot.configPublicKeyFile,
+		urlSource:           boot.urlSource,
+		dirSource:           boot.dirSource,
+		profiles:            boot.profiles,
+		hostname:            boot.hostname,
+	}
+}
+
+// swapConfig replace boot config, sources and files of it
+func (boot *Boot) swapConfig(config *yaml.Node, sources configProvenance, files []string) {
+	boot.configLock.Lock()
+	defer boot.configLock.Unlock()
+
+	boot.config, boot.sources, boot.configFiles = config, sources, files
+}
+
+// changedEntries returns entries whose sections are different between oldRoot and newRoot
+func (boot *Boot) changedEntries(oldRoot, newRoot *yaml.Node) []rkentry.Entry {
+	res := make([]rkentry.Entry, 0)
+
+	for _, m := range []map[string]map[string]rkentry.Entry{boot.pluginEntries, boot.userEntries, boot.webEntries} {
+		types := make([]string, 0, len(m))
+		for k := range m {
+			types = append(types, k)
+		}
+		sort.Strings(types)
+
+		for _, entryType := range types {
+			names := make([]string, 0, len(m[entryType]))
+			for k := range m[entryType] {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				e := m[entryType][name]
+				key := boot.entrySectionKey(e)
+				if len(key) < 1 {
+					continue
+				}
+
+				if !sameNode(entrySection(oldRoot, key, name), entrySection(newRoot, key, name)) {
+					res = append(res, e)
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// changedSections returns paths of items which are added or removed in newRoot and not owned by any entry,
+// like a new gin entry, sorted
+func (boot *Boot) changedSections(oldRoot, newRoot *yaml.Node) []string {
+	owned := map[string]bool{}
+	for _, m := range []map[string]map[string]rkentry.Entry{boot.pluginEntries, boot.userEntries, boot.webEntries} {
+		for _, byName := range m {
+			for name, e := range byName {
+				if key := boot.entrySectionKey(e); len(key) > 0 {
+					owned[strings.ToLower(joinConfigPath(key, name))] = true
+				}
+			}
+		}
+	}
+
+	oldItems, newItems := sectionItems(oldRoot), sectionItems(newRoot)
+
+	res := make([]string, 0)
+	for path := range newItems {
+		if _, ok := oldItems[path]; !ok && !owned[strings.ToLower(path)] {
+			res = append(res, path)
+		}
+	}
+	for path := range oldItems {
+		if _, ok := newItems[path]; !ok && !owned[strings.ToLower(path)] {
+			res = append(res, path)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// sectionItems returns items of top level sections keyed by path like gin.greeter, or gin[1] if item has no name
+func sectionItems(root *yaml.Node) map[string]*yaml.Node {
+	res := map[string]*yaml.Node{}
+	if root == nil {
+		return res
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+
+		items := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			items = value.Content
+		}
+
+		for j, item := range items {
+			path := key
+			if name := itemName(item); len(name) > 0 {
+				path = joinConfigPath(key, name)
+			} else if value.Kind == yaml.SequenceNode {
+				path = fmt.Sprintf("%s[%d]", key, j)
+			}
+			res[path] = item
+		}
+	}
+
+	return res
+}
+
+// entrySectionKey returns top level key of entry in boot config, empty if unknown.
+//
+// Keys of entries registered with RegisterUserEntry are recorded, others would be guessed from entry type with
+// declared keys and keys in boot config, like gin for GinEntry.
+func (boot *Boot) entrySectionKey(entry rkentry.Entry) string {
+	if v, ok := boot.entryKeys[entryKey(entry)]; ok {
+		return v
+	}
+
+	candidates, _ := configKeys()
+	root, _ := boot.configSnapshot()
+	if root != nil {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			candidates = append(candidates, root.Content[i].Value)
+		}
+	}
+
+	res, entryType := "", strings.ToLower(entry.GetType())
+	for _, v := range candidates {
+		if strings.HasPrefix(entryType, strings.ToLower(v)) && len(v) > len(res) {
+			res = v
+		}
+	}
+
+	return res
+}
+
+// entrySection returns section of entry with name under top level key
+func entrySection(root *yaml.Node, key, name string) *yaml.Node {
+	if root == nil {
+		return nil
+	}
+
+	_, node := mappingValue(root, key)
+	if node == nil {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return namedItem(node, name)
+	case yaml.MappingNode:
+		return node
+	}
+
+	return nil
+}
+
+// sameNode returns true if values of nodes are equal, comments and styles are ignored
+func sameNode(a, b *yaml.Node) bool {
+	var va, vb interface{}
+	if a != nil {
+		if err := a.Decode(&va); err != nil {
+			return false
+		}
+	}
+	if b != nil {
+		if err := b.Decode(&vb); err != nil {
+			return false
+		}
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
+
+// entryKey returns identity of entry
+func entryKey(entry rkentry.Entry) string {
+	return entry.GetType() + "/" + entry.GetName()
+}
+
+// configWatcher watches boot config files and SIGHUP
+type configWatcher struct {
+	fsWatcher *fsnotify.Watcher
+	ticker    *time.Ticker
+	sigCh     chan os.Signal
+	quitCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// startWatch start watching boot config files and SIGHUP
+func (boot *Boot) startWatch() error {
+	w := &configWatcher{
+		sigCh:  make(chan os.Signal, 1),
+		quitCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	if len(boot.configFiles) > 0 || boot.dirSource != nil {
+		fsWatcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		w.fsWatcher = fsWatcher
+
+		if err := boot.watchDirs(w); err != nil {
+			fsWatcher.Close()
+			return err
+		}
+	}
+
+	// remote boot config would be polled
+	if boot.urlSource != nil {
+		w.ticker = time.NewTicker(boot.urlSource.pollInterval)
+	}
+
+	// SIGHUP would trigger reloading instead of shutting down
+	signal.Stop(rkentry.GlobalAppCtx.GetShutdownSig())
+	signal.Notify(rkentry.GlobalAppCtx.GetShutdownSig(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(w.sigCh, syscall.SIGHUP)
+
+	boot.watcher = w
+	go boot.watch(w)
+
+	return nil
+}
+
+// stopWatch stop watching and restore SIGHUP as shutdown signal
+func (boot *Boot) stopWatch() {
+	w := boot.watcher
+	if w == nil {
+		return
+	}
+	boot.watcher = nil
+
+	close(w.quitCh)
+	<-w.doneCh
+
+	signal.Stop(w.sigCh)
+	signal.Notify(rkentry.GlobalAppCtx.GetShutdownSig(),
+		syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	if w.fsWatcher != nil {
+		w.fsWatcher.Close()
+	}
+
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+}
+
+// watchDirs watch directories of boot config files, since editors usually replace files instead of writing them
+func (boot *Boot) watchDirs(w *configWatcher) error {
+	if boot.dirSource != nil {
+		if err := w.fsWatcher.Add(boot.dirSource.dir); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range boot.configFiles {
+		if err := w.fsWatcher.Add(filepath.Dir(v)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isConfigFile returns true if name is one of boot config files, or change of boot config directory
+func (boot *Boot) isConfigFile(name string) bool {
+	boot.reloadLock.Lock()
+	defer boot.reloadLock.Unlock()
+
+	if boot.dirSource != nil && boot.dirSource.isChange(name) {
+		return true
+	}
+
+	for _, v := range boot.configFiles {
+		if filepath.Clean(v) == filepath.Clean(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watch reload boot config until stopped
+func (boot *Boot) watch(w *configWatcher) {
+	defer close(w.doneCh)
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if w.fsWatcher != nil {
+		events, errs = w.fsWatcher.Events, w.fsWatcher.Errors
+	}
+
+	var poll <-chan time.Time
+	if w.ticker != nil {
+		poll = w.ticker.C
+	}
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-w.quitCh:
+			return
+		case <-w.sigCh:
+			boot.reloadFrom(w, "signal SIGHUP")
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+			} else if boot.isConfigFile(event.Name) {
+				timer = time.After(watchDebounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+			} else {
+				rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn("Failed to watch boot config", zap.Error(err))
+			}
+		case <-poll:
+			changed, err := boot.urlSource.poll()
+			if err != nil {
+				rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn("Failed to poll boot config", zap.Error(err))
+			} else if changed {
+				boot.reloadFrom(w, "remote change")
+			}
+		case <-timer:
+			timer = nil
+			boot.reloadFrom(w, "file change")
+		}
+	}
+}
+
+// reloadFrom reload boot config and log result
+func (boot *Boot) reloadFrom(w *configWatcher, trigger string) {
+	logger := rkentry.GlobalAppCtx.GetLoggerEntryDefault()
+
+	ctx := context.WithValue(context.Background(), "eventId", boot.EventId)
+	if err := boot.Reload(ctx); err != nil {
+		logger.Error("Failed to reload boot config", zap.String("trigger", trigger), zap.Error(err))
+		return
+	}
+
+	// files may be changed by $include
+	if w.fsWatcher != nil {
+		boot.reloadLock.Lock()
+		err := boot.watchDirs(w)
+		boot.reloadLock.Unlock()
+		if err != nil {
+			logger.Warn("Failed to watch boot config", zap.Error(err))
+		}
+	}
+
+	logger.Info("Reloaded boot config", zap.String("trigger", trigger))
+}
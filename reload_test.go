@@ -0,0 +1,243 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	RegisterUserEntry("utReload", func(c utReloadConfig) (rkentry.Entry, error) {
+		return &utReloadEntry{name: c.Name, value: c.Value}, nil
+	})
+}
+
+type utReloadConfig struct {
+	Name    string `yaml:"name"`
+	Enabled bool   `yaml:"enabled"`
+	Value   string `yaml:"value"`
+}
+
+type utReloadEntry struct {
+	lock    sync.Mutex
+	name    string
+	value   string
+	reloads []string
+}
+
+func (entry *utReloadEntry) Reload(ctx context.Context, newRaw []byte) error {
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	node, err := lookupTopLevelNode(newRaw, "utReload")
+	if err != nil {
+		return err
+	}
+
+	config := utReloadConfig{}
+	if err := namedItem(node, entry.name).Decode(&config); err != nil {
+		return err
+	}
+
+	entry.reloads = append(entry.reloads, config.Value)
+	if config.Value == "fail" {
+		return errors.New("expected failure")
+	}
+	entry.value = config.Value
+
+	return nil
+}
+
+func (entry *utReloadEntry) Values() (string, []string) {
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	return entry.value, append([]string{}, entry.reloads...)
+}
+
+func (entry *utReloadEntry) Bootstrap(context.Context) {}
+
+func (entry *utReloadEntry) Interrupt(context.Context) {}
+
+func (entry *utReloadEntry) GetName() string {
+	return entry.name
+}
+
+func (entry *utReloadEntry) GetType() string {
+	return "UtReloadEntry"
+}
+
+func (entry *utReloadEntry) GetDescription() string {
+	return ""
+}
+
+func (entry *utReloadEntry) String() string {
+	return entry.name
+}
+
+func writeReloadConfig(t *testing.T, filePath, a, b string) {
+	config := `
+utReload:
+  - name: ut-a
+    enabled: true
+    value: ` + a + `
+  - name: ut-b
+    enabled: true
+    value: ` + b + `
+`
+	assert.Nil(t, os.WriteFile(filePath, []byte(config), 0644))
+}
+
+func TestBoot_Reload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "boot.yaml")
+	writeReloadConfig(t, filePath, "a1", "b1")
+
+	boot := NewBoot(WithBootConfigPath(filePath, nil))
+	a := MustGetEntry[*utReloadEntry](boot, "ut-a")
+	b := MustGetEntry[*utReloadEntry](boot, "ut-b")
+
+	// only entry whose section changed would be reloaded
+	writeReloadConfig(t, filePath, "a2", "b1")
+	assert.Nil(t, boot.Reload(context.Background()))
+	value, reloads := a.Values()
+	assert.Equal(t, "a2", value)
+	assert.Equal(t, []string{"a2"}, reloads)
+	_, reloads = b.Values()
+	assert.Empty(t, reloads)
+
+	// failed entry rolls back all entries reloaded
+	writeReloadConfig(t, filePath, "a3", "fail")
+	assert.NotNil(t, boot.Reload(context.Background()))
+	value, reloads = a.Values()
+	assert.Equal(t, "a2", value)
+	assert.Equal(t, []string{"a2", "a3", "a2"}, reloads)
+	value, reloads = b.Values()
+	assert.Equal(t, "b1", value)
+	assert.Equal(t, []string{"fail", "b1"}, reloads)
+
+	node, _ := lookupConfigNode(boot.config, "utReload.ut-a.value")
+	assert.Equal(t, "a2", node.Value)
+
+	// invalid config keeps previous one
+	assert.Nil(t, os.WriteFile(filePath, []byte("utReload: ["), 0644))
+	assert.NotNil(t, boot.Reload(context.Background()))
+	node, _ = lookupConfigNode(boot.config, "utReload.ut-a.value")
+	assert.Equal(t, "a2", node.Value)
+}
+
+func TestBoot_WithConfigWatch(t *testing.T) {
+	defer func(v time.Duration) { watchDebounce = v }(watchDebounce)
+	watchDebounce = 10 * time.Millisecond
+
+	filePath := filepath.Join(t.TempDir(), "boot.yaml")
+	writeReloadConfig(t, filePath, "a1", "b1")
+
+	boot := NewBoot(WithBootConfigPath(filePath, nil), WithConfigWatch())
+	a := MustGetEntry[*utReloadEntry](boot, "ut-a")
+	b := MustGetEntry[*utReloadEntry](boot, "ut-b")
+
+	boot.Bootstrap(context.Background())
+	defer boot.stopWatch()
+
+	// reload on file change
+	writeReloadConfig(t, filePath, "a2", "b1")
+	assert.Eventually(t, func() bool {
+		value, _ := a.Values()
+		return value == "a2"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// reload on SIGHUP
+	writeReloadConfig(t, filePath, "a2", "b2")
+	boot.watcher.sigCh <- syscall.SIGHUP
+	assert.Eventually(t, func() bool {
+		value, _ := b.Values()
+		return value == "b2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestBoot_ReloadConcurrentReads(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "boot.yaml")
+	writeReloadConfig(t, filePath, "a1", "b1")
+
+	boot := NewBoot(WithBootConfigPath(filePath, nil))
+
+	// config is read while reloading, run with -race to verify
+	quit := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-quit:
+				return
+			default:
+			}
+
+			source, ok := boot.ConfigSource("utReload.ut-a.value")
+			assert.True(t, ok)
+			assert.Equal(t, ConfigSourceFile, source.Kind)
+			_, err := boot.EffectiveConfig(ConfigFormatYAML)
+			assert.Nil(t, err)
+			config := utReloadConfig{}
+			assert.Nil(t, boot.UnmarshalKey("utReload.ut-a", &config))
+			boot.Lint()
+		}
+	}()
+
+	for _, v := range []string{"a2", "a3", "a4"} {
+		writeReloadConfig(t, filePath, v, "b1")
+		assert.Nil(t, boot.Reload(context.Background()))
+	}
+	close(quit)
+	wg.Wait()
+
+	config := utReloadConfig{}
+	assert.Nil(t, boot.UnmarshalKey("utReload.ut-a", &config))
+	assert.Equal(t, "a4", config.Value)
+}
+
+func TestBoot_ChangedSections(t *testing.T) {
+	oldRoot, _ := parseConfig([]byte(`
+utReload:
+  - name: ut-a
+  - name: ut-b
+gin:
+  - name: greeter
+app:
+  name: ut
+`), ConfigFormatYAML)
+	newRoot, _ := parseConfig([]byte(`
+utReload:
+  - name: ut-a
+  - name: ut-c
+echo:
+  - name: greeter
+app:
+  name: ut
+  version: v2
+`), ConfigFormatYAML)
+
+	boot := &Boot{
+		userEntries: map[string]map[string]rkentry.Entry{
+			"UtReloadEntry": {"ut-b": &utReloadEntry{name: "ut-b"}},
+		},
+		entryKeys: map[string]string{"UtReloadEntry/ut-b": "utReload"},
+	}
+
+	// ut-b is handled by entry, changed app is not added or removed
+	assert.Equal(t, []string{"echo.greeter", "gin.greeter", "utReload.ut-c"}, boot.changedSections(oldRoot, newRoot))
+}
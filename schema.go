@@ -0,0 +1,349 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// schemaLock guards configSchemas and regFuncKeys, since they may be registered while booting
+	schemaLock sync.RWMutex
+
+	// regFuncKeys is top level keys owned by reg funcs registered into rkentry, keyed by pointer of reg func
+	regFuncKeys = map[uintptr][]string{}
+
+	// configSchemas is schema of items of top level keys in boot config, nil means unknown schema
+	configSchemas = map[string]reflect.Type{
+		"app":    nil,
+		"logger": reflect.TypeOf(rkentry.BootLoggerE{}),
+		"event":  reflect.TypeOf(rkentry.BootEventE{}),
+		"cert":   reflect.TypeOf(rkentry.BootCertE{}),
+		"config": reflect.TypeOf(rkentry.BootConfigE{}),
+	}
+)
+
+// RegisterConfigSchema declare top level key and schema of it in boot config.
+//
+// schema is value of struct which one item of the section would be unmarshalled into, the section could be
+// either a single object or a list of objects. Pass nil if only key would be declared.
+//
+// Keys declared would be used by NewBoot while checking unknown keys, see WithStrictConfig for details.
+// Keys of entries registered with RegisterUserEntry would be declared automatically, reg funcs registered into
+// rkentry directly should declare keys they own with RegisterRegFuncKeys.
+func RegisterConfigSchema(yamlKey string, schema interface{}) {
+	if len(yamlKey) < 1 {
+		return
+	}
+
+	var t reflect.Type
+	if schema != nil {
+		t = reflect.TypeOf(schema)
+	}
+
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+	configSchemas[yamlKey] = t
+}
+
+// RegisterRegFuncKeys declare top level keys in boot config owned by reg func registered into rkentry, like
+// RegisterRegFuncKeys(rkgin.RegisterGinEntryYAML, "gin").
+//
+// Use RegisterConfigSchema as well to declare schema of keys. Reg funcs are identified by their code, so
+// closures created by the same function literal share keys.
+func RegisterRegFuncKeys(regFunc rkentry.RegFunc, yamlKeys ...string) {
+	if regFunc == nil {
+		return
+	}
+
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+
+	ptr := reflect.ValueOf(regFunc).Pointer()
+	for _, key := range yamlKeys {
+		if len(key) > 0 {
+			regFuncKeys[ptr] = append(regFuncKeys[ptr], key)
+		}
+	}
+}
+
+// configSchema returns declared schema of top level key, false if key is not declared
+func configSchema(yamlKey string) (reflect.Type, bool) {
+	schemaLock.RLock()
+	defer schemaLock.RUnlock()
+
+	t, ok := configSchemas[yamlKey]
+	return t, ok
+}
+
+// WithStrictConfig fail NewBoot on unknown keys in boot config.
+//
+// Unknown keys would be logged as warnings if strict mode is disabled, which is the default behavior.
+//
+// If any reg func registered into rkentry doesn't declare keys with RegisterRegFuncKeys, keys owned by it are
+// unknown, top level keys would be reported only if they look like typos of declared keys.
+func WithStrictConfig() BootOption {
+	return func(boot *Boot) {
+		boot.strictConfig = true
+	}
+}
+
+// configKeys returns declared top level keys and keys owned by reg funcs registered into rkentry, complete
+// would be false if keys owned by any reg func are unknown
+func configKeys() ([]string, bool) {
+	regFuncs := append(rkentry.ListPluginEntryRegFunc(), rkentry.ListUserEntryRegFunc()...)
+	regFuncs = append(regFuncs, rkentry.ListWebFrameEntryRegFunc()...)
+
+	return collectConfigKeys(regFuncs)
+}
+
+// collectConfigKeys returns declared top level keys and keys declared by regFuncs, sorted
+func collectConfigKeys(regFuncs []rkentry.RegFunc) ([]string, bool) {
+	schemaLock.RLock()
+	defer schemaLock.RUnlock()
+
+	keys := map[string]bool{}
+	for k := range configSchemas {
+		keys[k] = true
+	}
+
+	// reg funcs without declaration may own any key
+	complete := true
+	for _, f := range regFuncs {
+		declared, ok := regFuncKeys[reflect.ValueOf(f).Pointer()]
+		if !ok {
+			complete = false
+			continue
+		}
+
+		for _, key := range declared {
+			keys[key] = true
+		}
+	}
+
+	res := make([]string, 0, len(keys))
+	for k := range keys {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+
+	return res, complete
+}
+
+// regFuncDeclaredKeys returns keys declared by regFuncs with RegisterRegFuncKeys
+func regFuncDeclaredKeys(regFuncs []rkentry.RegFunc) map[string]bool {
+	schemaLock.RLock()
+	defer schemaLock.RUnlock()
+
+	res := map[string]bool{}
+	for _, f := range regFuncs {
+		for _, key := range regFuncKeys[reflect.ValueOf(f).Pointer()] {
+			res[key] = true
+		}
+	}
+
+	return res
+}
+
+// checkUnknownKeys returns message of unknown top level keys and unknown fields of declared schemas.
+//
+// If keys are not complete, unknown top level keys would be reported only if they are close to known keys,
+// since they may be owned by reg funcs which don't declare keys.
+func checkUnknownKeys(root *yaml.Node, candidates []string, complete bool) []string {
+	res := make([]string, 0)
+	checkUnknownSections(root, "", candidates, complete, &res)
+	return res
+}
+
+// checkUnknownSections check top level keys in root, like boot config or defaults section in it, whose path is prefix
+func checkUnknownSections(root *yaml.Node, prefix string, candidates []string, complete bool, res *[]string) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		known := ""
+		for _, v := range candidates {
+			if strings.EqualFold(v, key.Value) {
+				known = v
+				break
+			}
+		}
+
+		if len(known) < 1 {
+			if complete || len(closestWord(key.Value, candidates)) > 0 {
+				*res = append(*res, unknownKeyMessage(key, prefix, candidates))
+			}
+			continue
+		}
+
+		schema, _ := configSchema(known)
+		if schema == nil {
+			continue
+		}
+
+		path := joinConfigPath(prefix, key.Value)
+		if value.Kind == yaml.SequenceNode {
+			for j, item := range value.Content {
+				checkUnknownFields(schema, item, fmt.Sprintf("%s[%d]", path, j), res)
+			}
+		} else {
+			checkUnknownFields(schema, value, path, res)
+		}
+	}
+}
+
+// checkUnknownFields walk through node with schema and append message of unknown fields
+func checkUnknownFields(t reflect.Type, node *yaml.Node, path string, res *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		fields := schemaFields(t)
+		names := make([]string, 0, len(fields))
+		for _, v := range fields {
+			names = append(names, v.name)
+		}
+		sort.Strings(names)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			field, ok := fields[strings.ToLower(key.Value)]
+			if !ok {
+				*res = append(*res, unknownKeyMessage(key, path, names))
+				continue
+			}
+
+			checkUnknownFields(field.typ, value, joinConfigPath(path, key.Value), res)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+
+		for i, item := range node.Content {
+			checkUnknownFields(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), res)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkUnknownFields(t.Elem(), node.Content[i+1], joinConfigPath(path, node.Content[i].Value), res)
+		}
+	}
+}
+
+// schemaField is field of schema struct
+type schemaField struct {
+	name string
+	typ  reflect.Type
+}
+
+// schemaFields returns fields of struct keyed by lower cased yaml key, fields of inline structs are flattened
+func schemaFields(t reflect.Type) map[string]*schemaField {
+	res := map[string]*schemaField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline, skip := yamlFieldName(field)
+		if skip {
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if (inline || (field.Anonymous && len(field.Tag.Get("yaml")) < 1)) && ft.Kind() == reflect.Struct {
+			for k, v := range schemaFields(ft) {
+				res[k] = v
+			}
+			continue
+		}
+
+		// rkentry.UnmarshalBootYAML lower cases keys, so we match keys case-insensitive
+		res[strings.ToLower(name)] = &schemaField{name: name, typ: field.Type}
+	}
+
+	return res
+}
+
+// unknownKeyMessage returns message of unknown key with closest valid spelling
+func unknownKeyMessage(key *yaml.Node, path string, candidates []string) string {
+	res := fmt.Sprintf("unknown key %q", key.Value)
+	if len(path) > 0 {
+		res = fmt.Sprintf("%s in %s", res, path)
+	}
+	res = fmt.Sprintf("%s at line %d", res, key.Line)
+
+	if v := closestWord(key.Value, candidates); len(v) > 0 {
+		res = fmt.Sprintf("%s, did you mean %q?", res, v)
+	}
+
+	return res
+}
+
+// closestWord returns candidate which is close enough to word, empty string if none
+func closestWord(word string, candidates []string) string {
+	// allow one typo for short words and two for others
+	res, min := "", 2
+	if len(word) > 4 {
+		min = 3
+	}
+
+	for _, v := range candidates {
+		if d := levenshtein(strings.ToLower(word), strings.ToLower(v)); d < min {
+			res, min = v, d
+		}
+	}
+
+	return res
+}
+
+// levenshtein returns edit distance of two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j] + 1
+			if v := curr[j-1] + 1; v < curr[j] {
+				curr[j] = v
+			}
+			if v := prev[j-1] + cost; v < curr[j] {
+				curr[j] = v
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
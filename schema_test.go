@@ -0,0 +1,121 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"reflect"
+	"testing"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"github.com/stretchr/testify/assert"
+)
+
+type utSchemaEntry struct {
+	Name    string `yaml:"name"`
+	Enabled bool   `yaml:"enabled"`
+}
+
+func registerUtGinEntries(raw []byte) map[string]rkentry.Entry {
+	return nil
+}
+
+// RegisterUtLegacyEntryYAML follows naming of rk plugins but doesn't declare keys
+func RegisterUtLegacyEntryYAML(raw []byte) map[string]rkentry.Entry {
+	return nil
+}
+
+func TestCheckUnknownKeys(t *testing.T) {
+	RegisterConfigSchema("utSchemaEntry", utSchemaEntry{})
+	defer delete(configSchemas, "utSchemaEntry")
+	RegisterRegFuncKeys(registerUtGinEntries, "utGin")
+	defer delete(regFuncKeys, reflect.ValueOf(registerUtGinEntries).Pointer())
+
+	root, _ := parseConfig([]byte(`
+logger:
+  - name: my-logger
+    zap:
+      levle: info
+midleware: {}
+utSchemaEntry:
+  name: ut
+  enabeld: true
+utGin:
+  - name: greeter
+    anything: true
+loger: {}
+`), ConfigFormatYAML)
+
+	// keys of all reg funcs are declared
+	keys, complete := collectConfigKeys([]rkentry.RegFunc{registerUtGinEntries})
+	assert.True(t, complete)
+	assert.Contains(t, keys, "utGin")
+
+	res := checkUnknownKeys(root, keys, complete)
+	assert.Len(t, res, 4)
+	assert.Contains(t, res, `unknown key "levle" in logger[0].zap at line 5, did you mean "level"?`)
+	assert.Contains(t, res, `unknown key "midleware" at line 6`)
+	assert.Contains(t, res, `unknown key "enabeld" in utSchemaEntry at line 9, did you mean "enabled"?`)
+	assert.Contains(t, res, `unknown key "loger" at line 13, did you mean "logger"?`)
+
+	// keys owned by reg func without declaration are unknown even if name of it looks like RegisterGinEntryYAML,
+	// only typos of known keys are reported at top level
+	keys, complete = collectConfigKeys([]rkentry.RegFunc{registerUtGinEntries, RegisterUtLegacyEntryYAML})
+	assert.False(t, complete)
+	assert.NotContains(t, keys, "utLegacy")
+
+	res = checkUnknownKeys(root, keys, complete)
+	assert.Len(t, res, 3)
+	assert.NotContains(t, res, `unknown key "midleware" at line 6`)
+}
+
+func TestRegisterRegFuncKeys(t *testing.T) {
+	RegisterRegFuncKeys(registerUtGinEntries, "utGin", "", "utGinAlias")
+	defer delete(regFuncKeys, reflect.ValueOf(registerUtGinEntries).Pointer())
+	RegisterRegFuncKeys(nil, "utNil")
+
+	assert.Equal(t, map[string]bool{"utGin": true, "utGinAlias": true},
+		regFuncDeclaredKeys([]rkentry.RegFunc{registerUtGinEntries, RegisterUtLegacyEntryYAML}))
+
+	keys, complete := collectConfigKeys([]rkentry.RegFunc{registerUtGinEntries})
+	assert.True(t, complete)
+	assert.Contains(t, keys, "utGinAlias")
+	assert.NotContains(t, keys, "utNil")
+}
+
+func TestNewBootE_WithLegacyRegFunc(t *testing.T) {
+	// myEntry is owned by RegisterMyEntriesFromConfig which doesn't declare keys
+	boot, err := NewBootE(
+		WithBootConfigRaw([]byte("myEntry:\n  name: ut-legacy\n  enabled: true")),
+		WithStrictConfig())
+	assert.Nil(t, err)
+	assert.NotNil(t, boot.userEntries["myEntry"]["ut-legacy"])
+	rkentry.GlobalAppCtx.RemoveEntry(rkentry.GlobalAppCtx.GetEntry("myEntry", "ut-legacy"))
+}
+
+func TestNewBootE_WithStrictConfig(t *testing.T) {
+	boot, err := NewBootE(
+		WithBootConfigRaw([]byte("loger:\n  - name: my-logger")),
+		WithStrictConfig())
+	assert.Nil(t, boot)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `did you mean "logger"?`)
+}
+
+func TestClosestWord(t *testing.T) {
+	candidates := []string{"enabled", "name", "loggerEntry", "port"}
+
+	assert.Equal(t, "enabled", closestWord("enabeld", candidates))
+	assert.Equal(t, "loggerEntry", closestWord("LoggerEntri", candidates))
+	assert.Equal(t, "port", closestWord("prt", candidates))
+	assert.Empty(t, closestWord("description", candidates))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("gin", "gin"))
+	assert.Equal(t, 1, levenshtein("midleware", "middleware"))
+	assert.Equal(t, 2, levenshtein("enabeld", "enabled"))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+}
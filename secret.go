@@ -0,0 +1,114 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretRefPrefix is prefix of secret references in boot config, like secret://env/DB_PASS
+const secretRefPrefix = "secret://"
+
+// SecretResolver resolves secret references in boot config.
+//
+// A reference looks like secret://<provider>/<path>, resolver registered with provider name
+// would be called with path.
+type SecretResolver interface {
+	// Resolve returns value of secret
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// SecretResolverFunc is an adapter to allow the use of functions as SecretResolver
+type SecretResolverFunc func(ctx context.Context, path string) (string, error)
+
+// Resolve calls f(ctx, path)
+func (f SecretResolverFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+// WithSecretResolver register resolver of provider.
+//
+// Bellow providers are registered by default and could be overridden:
+//
+//	file: secret://file/run/secrets/db-pass reads content of /run/secrets/db-pass, trailing newline would be trimmed
+//	env:  secret://env/DB_PASS reads environment variable of DB_PASS
+func WithSecretResolver(provider string, resolver SecretResolver) BootOption {
+	return func(boot *Boot) {
+		if len(provider) > 0 && resolver != nil {
+			boot.secretResolvers[provider] = resolver
+		}
+	}
+}
+
+// defaultSecretResolvers returns resolvers registered by default
+func (boot *Boot) defaultSecretResolvers() map[string]SecretResolver {
+	return map[string]SecretResolver{
+		"file": SecretResolverFunc(func(ctx context.Context, path string) (string, error) {
+			bytes, err := os.ReadFile("/" + strings.TrimPrefix(path, "/"))
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(bytes), "\r\n"), nil
+		}),
+		"env": SecretResolverFunc(func(ctx context.Context, path string) (string, error) {
+			for _, v := range boot.environ {
+				if tokens := strings.SplitN(v, "=", 2); len(tokens) == 2 && tokens[0] == path {
+					return tokens[1], nil
+				}
+			}
+			return "", fmt.Errorf("environment variable %s not found", path)
+		}),
+	}
+}
+
+// resolveSecrets replace secret references in node with values from resolvers
+func (boot *Boot) resolveSecrets(ctx context.Context, node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		for _, v := range node.Content {
+			if err := boot.resolveSecrets(ctx, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(node.Value, secretRefPrefix) {
+		return nil
+	}
+
+	ref := node.Value
+	tokens := strings.SplitN(strings.TrimPrefix(ref, secretRefPrefix), "/", 2)
+	if len(tokens) != 2 || len(tokens[0]) < 1 || len(tokens[1]) < 1 {
+		return fmt.Errorf("invalid secret reference %s at line %d, expect secret://<provider>/<path>", ref, node.Line)
+	}
+
+	resolver, ok := boot.secretResolvers[tokens[0]]
+	if !ok {
+		return fmt.Errorf("secret resolver of provider %s not found, reference %s at line %d", tokens[0], ref, node.Line)
+	}
+
+	value, err := resolver.Resolve(ctx, tokens[1])
+	if err != nil {
+		return fmt.Errorf("failed to resolve secret %s at line %d, %v", ref, node.Line, err)
+	}
+
+	// keep file and line of reference, and mark node as secret which would be redacted in effective config
+	source := ConfigSource{}
+	if v, ok := boot.sources[node]; ok {
+		source = *v
+	}
+	source.Kind, source.Name = ConfigSourceSecret, ref
+	boot.sources[node] = &source
+
+	node.Tag, node.Style, node.Value = "!!str", 0, value
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoot_ResolveSecrets(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "db-pass")
+	assert.Nil(t, os.WriteFile(secretFile, []byte("file-pass\n"), 0600))
+
+	config := `
+mysql:
+  - name: user-db
+    pass: secret://file` + secretFile + `
+redis:
+  - name: cache
+    password: secret://env/UT_REDIS_PASS
+gin:
+  - name: greeter
+    middleware:
+      jwt:
+        symmetric:
+          token: secret://vault/kv/jwt
+`
+	vault := SecretResolverFunc(func(ctx context.Context, path string) (string, error) {
+		return "vault-" + path, nil
+	})
+
+	boot := NewBoot(
+		WithBootConfigRaw([]byte(config)),
+		WithSecretResolver("vault", vault),
+		func(boot *Boot) {
+			boot.environ = []string{"UT_REDIS_PASS=env-pass"}
+		})
+
+	node, _ := lookupConfigNode(boot.config, "mysql.user-db.pass")
+	assert.Equal(t, "file-pass", node.Value)
+	node, _ = lookupConfigNode(boot.config, "redis.cache.password")
+	assert.Equal(t, "env-pass", node.Value)
+	node, _ = lookupConfigNode(boot.config, "gin.greeter.middleware.jwt.symmetric.token")
+	assert.Equal(t, "vault-kv/jwt", node.Value)
+
+	res, _ := boot.ConfigSource("redis.cache.password")
+	assert.Equal(t, "secret://env/UT_REDIS_PASS from <raw>:7", res.String())
+
+	// resolved values would never appear in effective config, even if key doesn't look like secret
+	dump, err := boot.EffectiveConfig(ConfigFormatYAML)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(dump), "file-pass")
+	assert.NotContains(t, string(dump), "env-pass")
+	assert.NotContains(t, string(dump), "vault-kv/jwt")
+
+	boot = NewBoot(
+		WithBootConfigRaw([]byte("myEntry:\n  description: secret://env/UT_DESC")),
+		WithRedactPatterns(),
+		func(boot *Boot) {
+			boot.environ = []string{"UT_DESC=my-desc"}
+		})
+	dump, _ = boot.EffectiveConfig(ConfigFormatJSON)
+	assert.Contains(t, string(dump), `"description": "******"`)
+}
+
+func TestBoot_ResolveSecrets_WithAlias(t *testing.T) {
+	config := `
+x: &pw secret://env/ZZ_PW
+myEntry:
+  user: *pw
+`
+	boot := NewBoot(
+		WithBootConfigRaw([]byte(config)),
+		WithRedactPatterns(),
+		func(boot *Boot) {
+			boot.environ = []string{"ZZ_PW=my-pw"}
+		})
+
+	node, _ := lookupConfigNode(boot.config, "myEntry.user")
+	assert.Equal(t, "my-pw", node.Alias.Value)
+
+	for _, format := range []ConfigFormat{ConfigFormatYAML, ConfigFormatJSON} {
+		dump, err := boot.EffectiveConfig(format)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(dump), "my-pw")
+	}
+
+	dump, _ := boot.EffectiveConfig(ConfigFormatJSON)
+	assert.Contains(t, string(dump), `"user": "******"`)
+}
+
+func TestBoot_ResolveSecrets_WithError(t *testing.T) {
+	failed := SecretResolverFunc(func(ctx context.Context, path string) (string, error) {
+		return "", errors.New("expected error")
+	})
+
+	for _, v := range []string{
+		"secret://env",
+		"secret://missing/path",
+		"secret://env/UT_MISSING_ENV",
+		"secret://file/ut/missing/file",
+		"secret://failed/path",
+	} {
+		_, err := NewBootE(
+			WithBootConfigRaw([]byte("mysql:\n  - pass: "+v)),
+			WithSecretResolver("failed", failed),
+			func(boot *Boot) {
+				boot.environ = []string{}
+			})
+		assert.NotNil(t, err, v)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// signatureSuffix is suffix of detached signature file, like boot.yaml.sig
+const signatureSuffix = ".sig"
+
+// WithConfigPublicKey provide trusted ed25519 public key of verifying boot config.
+//
+// Every boot config file, including files referenced by $include, must have a detached signature
+// file next to it, like boot.yaml.sig, which contains base64 encoded signature of file content.
+// NewBoot would refuse to start if any signature is missing or mismatched.
+func WithConfigPublicKey(publicKey ed25519.PublicKey) BootOption {
+	return func(boot *Boot) {
+		boot.configPublicKey = publicKey
+	}
+}
+
+// WithConfigPublicKeyFile provide file which contains base64 encoded trusted ed25519 public key of verifying boot config.
+//
+// See WithConfigPublicKey for details.
+func WithConfigPublicKeyFile(filePath string) BootOption {
+	return func(boot *Boot) {
+		boot.configPublicKeyFile = filePath
+	}
+}
+
+// GenerateSigningKey returns base64 encoded ed25519 key pair of signing boot config.
+func GenerateSigningKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// ParseSigningPublicKey decode base64 encoded ed25519 public key.
+func ParseSigningPublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key, %v", err)
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key, expect %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// ParseSigningPrivateKey decode base64 encoded ed25519 private key.
+func ParseSigningPrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key, %v", err)
+	}
+
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key, expect %d bytes, got %d", ed25519.PrivateKeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// SignConfig returns base64 encoded detached signature of raw, which should be saved as <file>.sig.
+func SignConfig(privateKey ed25519.PrivateKey, raw []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, raw)) + "\n")
+}
+
+// VerifyConfig verify raw with base64 encoded detached signature.
+func VerifyConfig(publicKey ed25519.PublicKey, raw, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid signature, %v", err)
+	}
+
+	if !ed25519.Verify(publicKey, raw, sig) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+// publicKey returns trusted public key, nil if verification is disabled
+func (boot *Boot) publicKey() (ed25519.PublicKey, error) {
+	if len(boot.configPublicKeyFile) > 0 {
+		bytes, err := os.ReadFile(boot.configPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ParseSigningPublicKey(string(bytes))
+	}
+
+	return boot.configPublicKey, nil
+}
+
+// verifiedSource verify detached signature of every file read from source
+type verifiedSource struct {
+	configSource
+	publicKey ed25519.PublicKey
+}
+
+func (s verifiedSource) readFile(name string) ([]byte, error) {
+	raw, err := s.configSource.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := s.configSource.readFile(name + signatureSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature of %s, %v", name, err)
+	}
+
+	if err := VerifyConfig(s.publicKey, raw, sig); err != nil {
+		return nil, fmt.Errorf("failed to verify %s, %v", name, err)
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignConfig(t *testing.T) {
+	publicEncoded, privateEncoded, err := GenerateSigningKey()
+	assert.Nil(t, err)
+	publicKey, err := ParseSigningPublicKey(publicEncoded)
+	assert.Nil(t, err)
+	privateKey, err := ParseSigningPrivateKey(privateEncoded)
+	assert.Nil(t, err)
+
+	raw := []byte("myEntry:\n  name: ut\n")
+	sig := SignConfig(privateKey, raw)
+	assert.Nil(t, VerifyConfig(publicKey, raw, sig))
+
+	// with tampered content
+	assert.NotNil(t, VerifyConfig(publicKey, []byte("myEntry:\n  name: evil\n"), sig))
+
+	// with invalid signature
+	assert.NotNil(t, VerifyConfig(publicKey, raw, []byte("invalid")))
+
+	// with invalid keys
+	_, err = ParseSigningPublicKey(privateEncoded)
+	assert.NotNil(t, err)
+	_, err = ParseSigningPrivateKey(publicEncoded)
+	assert.NotNil(t, err)
+}
+
+func TestNewBoot_WithConfigPublicKey(t *testing.T) {
+	publicEncoded, privateEncoded, _ := GenerateSigningKey()
+	publicKey, _ := ParseSigningPublicKey(publicEncoded)
+	privateKey, _ := ParseSigningPrivateKey(privateEncoded)
+
+	main := []byte("$include: common.yaml\nmyEntry:\n  name: ut-signed\n")
+	common := []byte("myEntry:\n  enabled: true\n")
+
+	fsys := fstest.MapFS{
+		"boot.yaml":       &fstest.MapFile{Data: main},
+		"boot.yaml.sig":   &fstest.MapFile{Data: SignConfig(privateKey, main)},
+		"common.yaml":     &fstest.MapFile{Data: common},
+		"common.yaml.sig": &fstest.MapFile{Data: SignConfig(privateKey, common)},
+	}
+
+	// with valid signatures
+	boot, err := NewBootE(WithBootConfigFS(fsys, "boot.yaml"), WithConfigPublicKey(publicKey))
+	assert.Nil(t, err)
+	node, _ := lookupConfigNode(boot.config, "myEntry.name")
+	assert.Equal(t, "ut-signed", node.Value)
+
+	// with tampered include
+	fsys["common.yaml"] = &fstest.MapFile{Data: []byte("myEntry:\n  enabled: false\n")}
+	_, err = NewBootE(WithBootConfigFS(fsys, "boot.yaml"), WithConfigPublicKey(publicKey))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "common.yaml")
+
+	// with missing signature
+	fsys["common.yaml"] = &fstest.MapFile{Data: common}
+	delete(fsys, "boot.yaml.sig")
+	_, err = NewBootE(WithBootConfigFS(fsys, "boot.yaml"), WithConfigPublicKey(publicKey))
+	assert.NotNil(t, err)
+
+	// with raw config
+	_, err = NewBootE(WithBootConfigRaw(main), WithConfigPublicKey(publicKey))
+	assert.NotNil(t, err)
+}
+
+func TestNewBoot_WithConfigPublicKeyFile(t *testing.T) {
+	publicEncoded, privateEncoded, _ := GenerateSigningKey()
+	privateKey, _ := ParseSigningPrivateKey(privateEncoded)
+
+	dir := t.TempDir()
+	raw := []byte("myEntry:\n  name: ut-signed-file\n  enabled: true\n")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "boot.yaml"), raw, 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "boot.yaml.sig"), SignConfig(privateKey, raw), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "release.pub"), []byte(publicEncoded+"\n"), 0644))
+
+	boot, err := NewBootE(
+		WithBootConfigPath(filepath.Join(dir, "boot.yaml"), nil),
+		WithConfigPublicKeyFile(filepath.Join(dir, "release.pub")))
+	assert.Nil(t, err)
+	node, _ := lookupConfigNode(boot.config, "myEntry.name")
+	assert.Equal(t, "ut-signed-file", node.Value)
+
+	// with another trusted key
+	otherPublic, _, _ := GenerateSigningKey()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "release.pub"), []byte(otherPublic), 0644))
+	_, err = NewBootE(
+		WithBootConfigPath(filepath.Join(dir, "boot.yaml"), nil),
+		WithConfigPublicKeyFile(filepath.Join(dir, "release.pub")))
+	assert.NotNil(t, err)
+}
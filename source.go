@@ -0,0 +1,78 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// rawConfigName is name of boot config provided as raw, files referenced by it are relative to working directory
+const rawConfigName = "<raw>"
+
+// configSource is where boot config files would be read from
+type configSource interface {
+	// readFile returns content of file
+	readFile(name string) ([]byte, error)
+
+	// join returns name of file which is referenced by rel in file of base
+	join(base, rel string) string
+}
+
+// configLoader loads boot config from source and records provenance of values
+type configLoader struct {
+	src     configSource
+	sources configProvenance
+}
+
+// newConfigLoader create loader with source
+func newConfigLoader(src configSource) *configLoader {
+	return &configLoader{
+		src:     src,
+		sources: configProvenance{},
+	}
+}
+
+// osSource reads files from local file system
+type osSource struct{}
+
+func (osSource) readFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osSource) join(base, rel string) string {
+	if filepath.IsAbs(rel) {
+		return rel
+	}
+
+	return filepath.Join(filepath.Dir(base), rel)
+}
+
+// fsSource reads files from fs.FS
+type fsSource struct {
+	fsys fs.FS
+}
+
+func (s fsSource) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(s.fsys, name)
+}
+
+func (s fsSource) join(base, rel string) string {
+	return path.Join(path.Dir(base), rel)
+}
+
+// trackedSource records names of files read from source
+type trackedSource struct {
+	configSource
+	files *[]string
+}
+
+func (s trackedSource) readFile(name string) ([]byte, error) {
+	*s.files = append(*s.files, name)
+	return s.configSource.readFile(name)
+}
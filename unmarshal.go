@@ -0,0 +1,277 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalKey unmarshal section of boot config into out, out must be a pointer.
+//
+// key is path of section like mySection.endpoints or gin.greeter, list items could be addressed
+// either by [index] or by name field. Empty key means whole boot config.
+//
+// Bellow struct tags are supported beside yaml tag:
+//
+//	default:"value"     Value would be assigned if field is missing in boot config.
+//	validate:"rules"    Comma separated rules, supported rules are required, min=N, max=N and oneof=a b c.
+//	                    min and max compare length of string, slice and map, and value of numbers.
+//
+// Example:
+//
+//	type PartnerConfig struct {
+//	  Endpoint string `yaml:"endpoint" validate:"required"`
+//	  Port     int    `yaml:"port" default:"8080" validate:"min=1,max=65535"`
+//	}
+//
+//	cfg := &PartnerConfig{}
+//	err := boot.UnmarshalKey("partner", cfg)
+func (boot *Boot) UnmarshalKey(key string, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("out must be a non-nil pointer")
+	}
+
+	var node *yaml.Node
+	if config, _ := boot.configSnapshot(); config != nil {
+		node = config
+		if len(key) > 0 {
+			var err error
+			if node, err = lookupConfigNode(config, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	if node != nil {
+		if err := node.Decode(out); err != nil {
+			return fmt.Errorf("failed to unmarshal %s, %v", key, err)
+		}
+	}
+
+	if err := applyDefaults(rv.Elem(), node); err != nil {
+		return fmt.Errorf("failed to apply defaults of %s, %v", key, err)
+	}
+
+	errs := make([]string, 0)
+	validateValue(rv.Elem(), node, key, &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config, %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// yamlFieldName returns key of struct field in yaml as yaml.v3 does
+func yamlFieldName(field reflect.StructField) (name string, inline bool, skip bool) {
+	if len(field.PkgPath) > 0 && !field.Anonymous {
+		return "", false, true
+	}
+
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, v := range parts[1:] {
+		if v == "inline" {
+			inline = true
+		}
+	}
+
+	if len(parts[0]) > 0 {
+		return parts[0], inline, false
+	}
+
+	return strings.ToLower(field.Name), inline, false
+}
+
+// exactMappingValue returns value of key in mapping node, key is case-sensitive as yaml.v3 decoding does
+func exactMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// sequenceItem returns i-th item of sequence node
+func sequenceItem(node *yaml.Node, i int) *yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+		return nil
+	}
+
+	return node.Content[i]
+}
+
+// applyDefaults assign value of default tag to fields which are missing in node
+func applyDefaults(v reflect.Value, node *yaml.Node) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return applyDefaults(v.Elem(), node)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := applyDefaults(v.Index(i), sequenceItem(node, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, inline, skip := yamlFieldName(field)
+			if skip {
+				continue
+			}
+
+			child := node
+			if !inline {
+				child = exactMappingValue(node, name)
+			}
+
+			fv := v.Field(i)
+			if def, ok := field.Tag.Lookup("default"); ok && child == nil && fv.IsZero() {
+				if err := yaml.Unmarshal([]byte(def), fv.Addr().Interface()); err != nil {
+					return fmt.Errorf("invalid default value %q of field %s, %v", def, field.Name, err)
+				}
+			}
+
+			if err := applyDefaults(fv, child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateValue check validate tags of fields recursively, errors would be appended with path of field
+func validateValue(v reflect.Value, node *yaml.Node, path string, errs *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			validateValue(v.Elem(), node, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), sequenceItem(node, i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, inline, skip := yamlFieldName(field)
+			if skip {
+				continue
+			}
+
+			child, childPath := node, path
+			if !inline {
+				child = exactMappingValue(node, name)
+				childPath = joinConfigPath(path, name)
+			}
+
+			fv := v.Field(i)
+			for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+				if len(rule) < 1 {
+					continue
+				}
+				if err := checkRule(fv, rule); err != nil {
+					msg := fmt.Sprintf("%s: %v", childPath, err)
+					if child != nil {
+						msg = fmt.Sprintf("%s (line %d)", msg, child.Line)
+					}
+					*errs = append(*errs, msg)
+				}
+			}
+
+			validateValue(fv, child, childPath, errs)
+		}
+	}
+}
+
+// joinConfigPath join path and key with dot
+func joinConfigPath(path, key string) string {
+	if len(path) < 1 {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// checkRule check value with one rule of validate tag
+func checkRule(v reflect.Value, rule string) error {
+	name, param := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, param = rule[:i], rule[i+1:]
+	}
+
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return errors.New("value is required")
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Errorf("invalid validate rule %q", rule)
+		}
+
+		actual, ok := measure(v)
+		if !ok {
+			return fmt.Errorf("validate rule %q is not supported by %s", rule, v.Type())
+		}
+
+		if name == "min" && actual < limit {
+			return fmt.Errorf("must be greater than or equal to %s, got %v", param, actual)
+		}
+		if name == "max" && actual > limit {
+			return fmt.Errorf("must be less than or equal to %s, got %v", param, actual)
+		}
+	case "oneof":
+		actual := fmt.Sprintf("%v", v.Interface())
+		for _, option := range strings.Fields(param) {
+			if option == actual {
+				return nil
+			}
+		}
+		// value is not reported since it may be secret like password
+		return fmt.Errorf("must be one of [%s]", param)
+	default:
+		return fmt.Errorf("unknown validate rule %q", rule)
+	}
+
+	return nil
+}
+
+// measure returns length of string, slice and map, or value of numbers
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type partnerConfig struct {
+	Enabled   bool               `yaml:"enabled" default:"true"`
+	Timeout   string             `yaml:"timeout" default:"5s"`
+	Endpoints []*partnerEndpoint `yaml:"endpoints" validate:"min=1"`
+}
+
+type partnerEndpoint struct {
+	Name   string `yaml:"name" validate:"required"`
+	Port   int    `yaml:"port" default:"8080" validate:"min=1,max=65535"`
+	Scheme string `yaml:"scheme" default:"https" validate:"oneof=http https"`
+}
+
+func TestBoot_UnmarshalKey(t *testing.T) {
+	config := `
+partner:
+  enabled: false
+  endpoints:
+    - name: alpha
+    - name: beta
+      port: 9090
+      scheme: http
+`
+	boot := NewBoot(WithBootConfigRaw([]byte(config)))
+
+	cfg := &partnerConfig{}
+	assert.Nil(t, boot.UnmarshalKey("partner", cfg))
+	assert.False(t, cfg.Enabled)
+	assert.Equal(t, "5s", cfg.Timeout)
+	assert.Len(t, cfg.Endpoints, 2)
+	assert.Equal(t, 8080, cfg.Endpoints[0].Port)
+	assert.Equal(t, "https", cfg.Endpoints[0].Scheme)
+	assert.Equal(t, 9090, cfg.Endpoints[1].Port)
+	assert.Equal(t, "http", cfg.Endpoints[1].Scheme)
+
+	// list item addressed by name
+	endpoint := &partnerEndpoint{}
+	assert.Nil(t, boot.UnmarshalKey("partner.endpoints.beta", endpoint))
+	assert.Equal(t, 9090, endpoint.Port)
+
+	// missing section, defaults applied and validated
+	cfg = &partnerConfig{}
+	err := boot.UnmarshalKey("missing", cfg)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "missing.endpoints: must be greater than or equal to 1")
+	assert.True(t, cfg.Enabled)
+
+	// non-pointer
+	assert.NotNil(t, boot.UnmarshalKey("partner", partnerConfig{}))
+}
+
+func TestBoot_UnmarshalKey_WithInvalidValue(t *testing.T) {
+	config := `
+partner:
+  endpoints:
+    - port: 70000
+      scheme: ftp
+`
+	boot := NewBoot(WithBootConfigRaw([]byte(config)))
+
+	err := boot.UnmarshalKey("partner", &partnerConfig{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "partner.endpoints[0].name: value is required")
+	assert.Contains(t, err.Error(), "partner.endpoints[0].port: must be less than or equal to 65535, got 70000 (line 4)")
+	assert.Contains(t, err.Error(), `partner.endpoints[0].scheme: must be one of [http https] (line 5)`)
+	assert.NotContains(t, err.Error(), "ftp")
+
+	// type mismatch
+	err = boot.UnmarshalKey("partner.endpoints[0].scheme", new(int))
+	assert.NotNil(t, err)
+}
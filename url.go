@@ -0,0 +1,305 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	rkentry "github.com/rookie-ninja/rk-entry/v2/entry"
+	"go.uber.org/zap"
+)
+
+// defaultPollInterval is interval of polling remote boot config while watching
+const defaultPollInterval = 30 * time.Second
+
+// URLOption is used as options of remote boot config source
+type URLOption func(*urlSource)
+
+// WithURLHeader add header into requests of fetching boot config, like Authorization.
+//
+// Headers are sent only to the same scheme and host of boot config URL, files included from other hosts
+// would be fetched without them.
+func WithURLHeader(key, value string) URLOption {
+	return func(s *urlSource) {
+		s.header.Add(key, value)
+	}
+}
+
+// WithURLCacheFile provide file of last-known-good boot config.
+//
+// Boot config would be saved into the file after fetched and parsed successfully, and would be used
+// while config service is unreachable.
+func WithURLCacheFile(filePath string) URLOption {
+	return func(s *urlSource) {
+		s.cacheFile = filePath
+	}
+}
+
+// WithURLPollInterval provide interval of polling boot config with ETag while watching, 30s by default.
+func WithURLPollInterval(interval time.Duration) URLOption {
+	return func(s *urlSource) {
+		if interval > 0 {
+			s.pollInterval = interval
+		}
+	}
+}
+
+// WithURLClient provide http client of fetching boot config.
+func WithURLClient(client *http.Client) URLOption {
+	return func(s *urlSource) {
+		if client != nil {
+			s.client = client
+		}
+	}
+}
+
+// WithBootConfigURL provide URL of boot config served over HTTP.
+//
+// Files referenced by $include are resolved relative to the URL. With WithConfigWatch, the URL and
+// files included would be polled with If-None-Match and boot config would be reloaded once changed.
+func WithBootConfigURL(configURL string, opts ...URLOption) BootOption {
+	return func(boot *Boot) {
+		s := &urlSource{
+			url:          configURL,
+			header:       http.Header{},
+			client:       &http.Client{Timeout: 10 * time.Second},
+			pollInterval: defaultPollInterval,
+			docs:         map[string]*urlDoc{},
+		}
+
+		for i := range opts {
+			opts[i](s)
+		}
+
+		boot.urlSource = s
+	}
+}
+
+// urlDoc is document fetched from URL
+type urlDoc struct {
+	ETag string `json:"etag"`
+	Body []byte `json:"body"`
+}
+
+// urlSource reads files from HTTP server
+type urlSource struct {
+	url          string
+	header       http.Header
+	client       *http.Client
+	cacheFile    string
+	pollInterval time.Duration
+
+	lock sync.Mutex
+	// docs fetched from server, keyed by URL
+	docs map[string]*urlDoc
+	// staged is docs read while loading boot config, committed into docs once boot config loaded
+	staged map[string]*urlDoc
+	// cache is last-known-good documents loaded from cache file
+	cache map[string]*urlDoc
+}
+
+func (s *urlSource) readFile(name string) ([]byte, error) {
+	_, doc, err := s.fetch(name)
+	if err == nil {
+		s.stage(name, doc)
+		return doc.Body, nil
+	}
+
+	if cached := s.cached(name); cached != nil {
+		rkentry.GlobalAppCtx.GetLoggerEntryDefault().Warn("Failed to fetch boot config, use cached one",
+			zap.String("url", name),
+			zap.String("cacheFile", s.cacheFile),
+			zap.Error(err))
+
+		s.stage(name, cached)
+		return cached.Body, nil
+	}
+
+	return nil, err
+}
+
+func (s *urlSource) join(base, rel string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return rel
+	}
+
+	relURL, err := url.Parse(rel)
+	if err != nil {
+		return rel
+	}
+
+	return baseURL.ResolveReference(relURL).String()
+}
+
+// sameOrigin returns true if u has the same scheme and host of boot config URL
+func (s *urlSource) sameOrigin(u *url.URL) bool {
+	origin, err := url.Parse(s.url)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(origin.Scheme, u.Scheme) && strings.EqualFold(origin.Host, u.Host)
+}
+
+// fetch get document of name with If-None-Match, returns true if document changed since last committed one.
+// Documents fetched are not committed, see stage and commit.
+func (s *urlSource) fetch(name string) (bool, *urlDoc, error) {
+	s.lock.Lock()
+	prev := s.docs[name]
+	s.lock.Unlock()
+
+	req, err := http.NewRequest(http.MethodGet, name, nil)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// do not leak headers like Authorization to other hosts referenced by $include
+	if s.sameOrigin(req.URL) {
+		for k, v := range s.header {
+			req.Header[k] = v
+		}
+	}
+	if prev != nil && len(prev.ETag) > 0 {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && prev != nil {
+		return false, prev, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil, fmt.Errorf("failed to fetch %s, status %s", name, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, nil, err
+	}
+
+	doc := &urlDoc{ETag: resp.Header.Get("ETag"), Body: body}
+	return prev == nil || !bytes.Equal(prev.Body, body), doc, nil
+}
+
+// stage record document read while loading boot config
+func (s *urlSource) stage(name string, doc *urlDoc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.staged == nil {
+		s.staged = map[string]*urlDoc{}
+	}
+	s.staged[name] = doc
+}
+
+// discard drop documents staged
+func (s *urlSource) discard() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.staged = nil
+}
+
+// commit replace documents with staged ones and save them into cache file as last-known-good boot config.
+// It should be called only after boot config loaded, so that a failed reload would be retried by next poll
+// instead of being treated as not modified.
+func (s *urlSource) commit() error {
+	s.lock.Lock()
+	staged := s.staged
+	s.staged = nil
+	if len(staged) > 0 {
+		s.docs = staged
+	}
+	s.lock.Unlock()
+
+	if len(staged) < 1 {
+		return nil
+	}
+
+	return s.saveCache()
+}
+
+// poll fetch documents committed, returns true if any of them changed
+func (s *urlSource) poll() (bool, error) {
+	s.lock.Lock()
+	names := make([]string, 0, len(s.docs))
+	for k := range s.docs {
+		names = append(names, k)
+	}
+	s.lock.Unlock()
+
+	res := false
+	for _, name := range names {
+		changed, _, err := s.fetch(name)
+		if err != nil {
+			return false, err
+		}
+		res = res || changed
+	}
+
+	return res, nil
+}
+
+// cached returns document of name in cache file, nil if missing
+func (s *urlSource) cached(name string) *urlDoc {
+	if len(s.cacheFile) < 1 {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.cache == nil {
+		s.cache = map[string]*urlDoc{}
+		if raw, err := os.ReadFile(s.cacheFile); err == nil {
+			_ = json.Unmarshal(raw, &s.cache)
+		}
+	}
+
+	return s.cache[name]
+}
+
+// saveCache save documents into cache file as last-known-good boot config
+func (s *urlSource) saveCache() error {
+	if len(s.cacheFile) < 1 {
+		return nil
+	}
+
+	s.lock.Lock()
+	docs := map[string]*urlDoc{}
+	for k, v := range s.docs {
+		docs[k] = v
+	}
+	s.lock.Unlock()
+
+	raw, err := json.Marshal(docs)
+	if err != nil {
+		return err
+	}
+
+	// write into temporary file first, so that cache file would never be partially written
+	tmp := s.cacheFile + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.cacheFile)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// utConfigServer serves documents with ETag and Authorization check
+type utConfigServer struct {
+	lock sync.Mutex
+	docs map[string]string
+	down bool
+}
+
+func (s *utConfigServer) set(path, doc string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.docs[path] = doc
+}
+
+func (s *utConfigServer) setDown(down bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.down = down
+}
+
+func (s *utConfigServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer ut-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	doc, ok := s.docs[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(doc)))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	_, _ = w.Write([]byte(doc))
+}
+
+func TestNewBoot_WithBootConfigURL(t *testing.T) {
+	handler := &utConfigServer{docs: map[string]string{
+		"/conf/boot.yaml":   "$include: common.yaml\nmyEntry:\n  name: ut-url\n",
+		"/conf/common.yaml": "myEntry:\n  enabled: true\n",
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "boot-cache.json")
+	configURL := server.URL + "/conf/boot.yaml"
+
+	// with auth header
+	boot, err := NewBootE(WithBootConfigURL(configURL,
+		WithURLHeader("Authorization", "Bearer ut-token"),
+		WithURLCacheFile(cacheFile)))
+	assert.Nil(t, err)
+	node, _ := lookupConfigNode(boot.config, "myEntry.name")
+	assert.Equal(t, "ut-url", node.Value)
+	res, _ := boot.ConfigSource("myEntry.enabled")
+	assert.Equal(t, server.URL+"/conf/common.yaml:2", res.String())
+
+	// without auth header
+	_, err = NewBootE(WithBootConfigURL(configURL))
+	assert.NotNil(t, err)
+
+	// cold start from last-known-good cache while server is unreachable
+	handler.setDown(true)
+	boot, err = NewBootE(WithBootConfigURL(configURL,
+		WithURLHeader("Authorization", "Bearer ut-token"),
+		WithURLCacheFile(cacheFile)))
+	assert.Nil(t, err)
+	node, _ = lookupConfigNode(boot.config, "myEntry.enabled")
+	assert.Equal(t, "true", node.Value)
+
+	// without cache
+	_, err = NewBootE(WithBootConfigURL(configURL,
+		WithURLHeader("Authorization", "Bearer ut-token"),
+		WithURLCacheFile(filepath.Join(t.TempDir(), "missing.json"))))
+	assert.NotNil(t, err)
+}
+
+func TestUrlSource_Poll(t *testing.T) {
+	handler := &utConfigServer{docs: map[string]string{
+		"/boot.yaml": "myEntry:\n  name: ut-url\n",
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	boot, err := NewBootE(WithBootConfigURL(server.URL+"/boot.yaml",
+		WithURLHeader("Authorization", "Bearer ut-token")))
+	assert.Nil(t, err)
+
+	// not modified
+	changed, err := boot.urlSource.poll()
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	handler.set("/boot.yaml", "myEntry:\n  name: ut-url-changed\n")
+	changed, err = boot.urlSource.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+
+	assert.Nil(t, boot.Reload(context.Background()))
+	node, _ := lookupConfigNode(boot.config, "myEntry.name")
+	assert.Equal(t, "ut-url-changed", node.Value)
+
+	// change is polled again after failed reload
+	handler.set("/boot.yaml", "myEntry: [")
+	changed, err = boot.urlSource.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.NotNil(t, boot.Reload(context.Background()))
+
+	changed, err = boot.urlSource.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+
+	handler.set("/boot.yaml", "myEntry:\n  name: ut-url-fixed\n")
+	assert.Nil(t, boot.Reload(context.Background()))
+	changed, err = boot.urlSource.poll()
+	assert.Nil(t, err)
+	assert.False(t, changed)
+}
+
+func TestUrlSource_HeaderOfOtherHost(t *testing.T) {
+	// other host records Authorization header it received
+	var auth []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("myEntry:\n  enabled: true\n"))
+	}))
+	defer other.Close()
+
+	handler := &utConfigServer{docs: map[string]string{
+		"/boot.yaml": fmt.Sprintf("$include: %s/common.yaml\nmyEntry:\n  name: ut-url-other\n", other.URL),
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	boot, err := NewBootE(WithBootConfigURL(server.URL+"/boot.yaml",
+		WithURLHeader("Authorization", "Bearer ut-token")))
+	assert.Nil(t, err)
+	node, _ := lookupConfigNode(boot.config, "myEntry.enabled")
+	assert.Equal(t, "true", node.Value)
+	assert.Equal(t, []string{""}, auth)
+
+	u, _ := url.Parse(server.URL + "/other.yaml")
+	assert.True(t, boot.urlSource.sameOrigin(u))
+	u, _ = url.Parse(strings.Replace(server.URL, "http://", "https://", 1))
+	assert.False(t, boot.urlSource.sameOrigin(u))
+}
+
+func TestNewBoot_WithBootConfigURLWatch(t *testing.T) {
+	config := "utReload:\n  - name: ut-url-a\n    enabled: true\n    value: %s\n"
+	handler := &utConfigServer{docs: map[string]string{
+		"/boot.yaml": fmt.Sprintf(config, "a1"),
+	}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	boot := NewBoot(
+		WithBootConfigURL(server.URL+"/boot.yaml",
+			WithURLHeader("Authorization", "Bearer ut-token"),
+			WithURLPollInterval(10*time.Millisecond)),
+		WithConfigWatch())
+	a := MustGetEntry[*utReloadEntry](boot, "ut-url-a")
+
+	boot.Bootstrap(context.Background())
+	defer boot.stopWatch()
+
+	handler.set("/boot.yaml", fmt.Sprintf(config, "a2"))
+	assert.Eventually(t, func() bool {
+		value, _ := a.Values()
+		return value == "a2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// whenKey is key of conditions of items in boot config
+	whenKey = "when"
+	// profileEnv is environment variable of active profiles, separated by comma
+	profileEnv = "RK_PROFILE"
+)
+
+// WithProfile provide active profiles which are used by when conditions in boot config,
+// environment variable of RK_PROFILE would be used if not provided.
+func WithProfile(profiles ...string) BootOption {
+	return func(boot *Boot) {
+		boot.profiles = append(boot.profiles, profiles...)
+	}
+}
+
+// whenCondition is conditions of an item in boot config, all of them must be matched.
+//
+//	when:
+//	  profile: [dev, test]          # one of active profiles is in the list
+//	  env: { ENABLE_PPROF: "true" } # all environment variables equal to values
+//	  hostname: [dev-*]             # hostname matches one of patterns
+type whenCondition struct {
+	Profile  []string          `yaml:"profile"`
+	Env      map[string]string `yaml:"env"`
+	Hostname []string          `yaml:"hostname"`
+}
+
+// activeProfiles returns profiles provided by WithProfile or RK_PROFILE
+func (boot *Boot) activeProfiles() []string {
+	if len(boot.profiles) > 0 {
+		return boot.profiles
+	}
+
+	res := make([]string, 0)
+	for _, v := range boot.environ {
+		if strings.HasPrefix(v, profileEnv+"=") {
+			for _, p := range strings.Split(strings.TrimPrefix(v, profileEnv+"="), ",") {
+				if p = strings.TrimSpace(p); len(p) > 0 {
+					res = append(res, p)
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// stripConditional remove items of top level sections whose when conditions are not matched,
+// when keys of matched items would be removed. Paths of items removed would be returned.
+func (boot *Boot) stripConditional(root *yaml.Node) ([]string, error) {
+	res := make([]string, 0)
+
+	content := make([]*yaml.Node, 0, len(root.Content))
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		switch value.Kind {
+		case yaml.MappingNode:
+			matched, err := boot.matchWhen(value, key.Value)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				res = append(res, key.Value)
+				continue
+			}
+		case yaml.SequenceNode:
+			items := make([]*yaml.Node, 0, len(value.Content))
+			for j, item := range value.Content {
+				itemPath := fmt.Sprintf("%s[%d]", key.Value, j)
+				matched, err := boot.matchWhen(item, itemPath)
+				if err != nil {
+					return nil, err
+				}
+				if !matched {
+					if name := itemName(item); len(name) > 0 {
+						itemPath = key.Value + "." + name
+					}
+					res = append(res, itemPath)
+					continue
+				}
+				items = append(items, item)
+			}
+			value.Content = items
+		}
+
+		content = append(content, key, value)
+	}
+	root.Content = content
+
+	return res, nil
+}
+
+// matchWhen evaluate when conditions of item and remove when key from it
+func (boot *Boot) matchWhen(item *yaml.Node, itemPath string) (bool, error) {
+	if item.Kind != yaml.MappingNode {
+		return true, nil
+	}
+
+	var node *yaml.Node
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		if item.Content[i].Value == whenKey {
+			node = item.Content[i+1]
+			item.Content = append(item.Content[:i:i], item.Content[i+2:]...)
+			break
+		}
+	}
+
+	if node == nil {
+		return true, nil
+	}
+
+	cond := &whenCondition{}
+	if err := node.Decode(cond); err != nil {
+		return false, fmt.Errorf("invalid when condition of %s at line %d, %v", itemPath, node.Line, err)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "profile", "env", "hostname":
+		default:
+			return false, fmt.Errorf("unknown when condition %q of %s at line %d, expect profile, env or hostname",
+				node.Content[i].Value, itemPath, node.Content[i].Line)
+		}
+	}
+
+	if len(cond.Profile) > 0 && !containsAny(cond.Profile, boot.activeProfiles()) {
+		return false, nil
+	}
+
+	for k, v := range cond.Env {
+		if !containsAny([]string{k + "=" + v}, boot.environ) {
+			return false, nil
+		}
+	}
+
+	if len(cond.Hostname) > 0 {
+		matched := false
+		for _, pattern := range cond.Hostname {
+			ok, err := path.Match(pattern, boot.hostname)
+			if err != nil {
+				return false, fmt.Errorf("invalid hostname pattern %q of %s at line %d, %v", pattern, itemPath, node.Line, err)
+			}
+			matched = matched || ok
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// containsAny returns true if any of values is in list
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		for _, l := range list {
+			if l == v {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 rookie-ninja
+//
+// Use of this source code is governed by an Apache-style
+// license that can be found in the LICENSE file.
+
+package rkboot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBoot_WithWhenCondition(t *testing.T) {
+	config := `
+gin:
+  - name: greeter
+    port: 8080
+  - name: pprof
+    port: 8081
+    when:
+      profile: [dev]
+      env:
+        ENABLE_PPROF: "true"
+mysql:
+  - name: dev-db
+    when:
+      hostname: [dev-*]
+  - name: prod-db
+    when:
+      profile: [prod]
+redis:
+  name: local
+  when:
+    profile: [local]
+`
+
+	newBoot := func(opts ...BootOption) *Boot {
+		opts = append([]BootOption{
+			WithBootConfigRaw([]byte(config)),
+			func(boot *Boot) {
+				boot.environ = []string{"ENABLE_PPROF=true", "RK_PROFILE=dev, local"}
+				boot.hostname = "dev-01"
+			},
+		}, opts...)
+		boot, err := NewBootE(opts...)
+		assert.Nil(t, err)
+		return boot
+	}
+
+	// with profiles from RK_PROFILE
+	boot := newBoot()
+	node, _ := lookupConfigNode(boot.config, "gin.pprof.port")
+	assert.NotNil(t, node)
+	node, _ = lookupConfigNode(boot.config, "gin.pprof.when")
+	assert.Nil(t, node)
+	node, _ = lookupConfigNode(boot.config, "mysql.dev-db")
+	assert.NotNil(t, node)
+	node, _ = lookupConfigNode(boot.config, "mysql.prod-db")
+	assert.Nil(t, node)
+	node, _ = lookupConfigNode(boot.config, "redis.name")
+	assert.NotNil(t, node)
+
+	// with profile provided
+	boot = newBoot(WithProfile("prod"), func(boot *Boot) {
+		boot.hostname = "prod-01"
+	})
+	node, _ = lookupConfigNode(boot.config, "gin.greeter")
+	assert.NotNil(t, node)
+	node, _ = lookupConfigNode(boot.config, "gin.pprof")
+	assert.Nil(t, node)
+	node, _ = lookupConfigNode(boot.config, "mysql.dev-db")
+	assert.Nil(t, node)
+	node, _ = lookupConfigNode(boot.config, "mysql.prod-db")
+	assert.NotNil(t, node)
+	node, _ = lookupConfigNode(boot.config, "redis")
+	assert.Nil(t, node)
+
+	// env mismatched
+	boot = newBoot(func(boot *Boot) {
+		boot.environ = []string{"ENABLE_PPROF=false", "RK_PROFILE=dev"}
+	})
+	node, _ = lookupConfigNode(boot.config, "gin.pprof")
+	assert.Nil(t, node)
+}
+
+func TestNewBoot_WithInvalidWhenCondition(t *testing.T) {
+	// unknown condition
+	_, err := NewBootE(WithBootConfigRaw([]byte("gin:\n  - name: greeter\n    when:\n      profiles: [dev]\n")))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "profiles")
+
+	// invalid type
+	_, err = NewBootE(WithBootConfigRaw([]byte("gin:\n  - name: greeter\n    when:\n      env: [dev]\n")))
+	assert.NotNil(t, err)
+}